@@ -22,7 +22,9 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/icemarkom/secure-backup/internal/archive"
 	"github.com/icemarkom/secure-backup/internal/backup"
 	"github.com/icemarkom/secure-backup/internal/common"
 	"github.com/icemarkom/secure-backup/internal/compress"
@@ -35,17 +37,32 @@ import (
 )
 
 var (
-	backupSource       string
-	backupDest         string
-	backupRecipient    string
-	backupPublicKey    string
-	backupVerbose      bool
-	backupDryRun       bool
-	backupEncryption   string
-	backupCompression  string
-	backupRetention    int
-	backupSkipManifest bool
-	backupFileMode     string
+	backupSources       []string
+	backupDest          string
+	backupRecipient     string
+	backupPublicKey     string
+	backupVerbose       bool
+	backupDryRun        bool
+	backupEncryption    string
+	backupCompression   string
+	backupCompLevel     int
+	backupThreads       int
+	backupZstdWindow    string
+	backupRetention     int
+	backupSkipManifest  bool
+	backupFileMode      string
+	backupExcludes      []string
+	backupIncludes      []string
+	backupExcludeFrom   []string
+	backupIgnoreFile    string
+	backupExcludeCaches bool
+	backupSpecialFiles  string
+	backupOneFileSystem bool
+	backupFailOnChange  bool
+	backupContinue      bool
+	backupReproducible  bool
+	backupClampMtime    string
+	backupReadAhead     string
 )
 
 var backupCmd = &cobra.Command{
@@ -60,7 +77,7 @@ func init() {
 	backupCmd.Long = fmt.Sprintf(`Create an encrypted, compressed backup of a directory.
 
 The backup pipeline follows this order (critical for compression):
-  1. TAR - Archive the source directory
+  1. TAR - Archive the source directories
   2. COMPRESS - Compress the tar archive (%s)
   3. ENCRYPT - Encrypt the compressed archive (%s)
 
@@ -73,17 +90,32 @@ Encryption methods:
 		strings.ToUpper(encrypt.MethodGPG), strings.ToUpper(encrypt.MethodGPG),
 		strings.ToUpper(encrypt.MethodAGE), strings.ToUpper(encrypt.MethodAGE))
 
-	backupCmd.Flags().StringVar(&backupSource, "source", "", "Source directory to backup (required)")
+	backupCmd.Flags().StringArrayVar(&backupSources, "source", nil, "Source directory to backup (required, repeatable)")
 	backupCmd.Flags().StringVar(&backupDest, "dest", "", "Destination directory for backup file (required)")
 	backupCmd.Flags().StringVar(&backupRecipient, "recipient", "", "GPG recipient email or key ID")
 	backupCmd.Flags().StringVar(&backupPublicKey, "public-key", "", fmt.Sprintf("Public key: GPG key file path (--encryption %s) or AGE recipient string (--encryption %s)", encrypt.MethodGPG, encrypt.MethodAGE))
 	backupCmd.Flags().StringVar(&backupEncryption, "encryption", encrypt.MethodGPG, fmt.Sprintf("Encryption method: %s (default: %s)", encrypt.ValidMethodNames(), encrypt.MethodGPG))
 	backupCmd.Flags().StringVar(&backupCompression, "compression", compress.MethodGzip, fmt.Sprintf("Compression method: %s (default: %s)", compress.ValidMethodNames(), compress.MethodGzip))
+	backupCmd.Flags().IntVar(&backupCompLevel, "compression-level", 0, fmt.Sprintf("Compression level, 0 = method default: %s", compress.LevelRanges()))
+	backupCmd.Flags().IntVar(&backupThreads, "threads", 0, "Compression threads (0 = all CPUs for gzip, zstd and s2, one for lz4)")
+	backupCmd.Flags().StringVar(&backupZstdWindow, "zstd-window", "", "Zstd window size for long-range matches, a power of two up to 128MiB (default: set by level, at most 8MiB)")
 	backupCmd.Flags().IntVar(&backupRetention, "retention", retention.DefaultKeepLast, "Number of backups to keep (0 = keep all)")
 	backupCmd.Flags().BoolVarP(&backupVerbose, "verbose", "v", false, "Verbose output")
 	backupCmd.Flags().BoolVar(&backupDryRun, "dry-run", false, "Preview backup without executing")
 	backupCmd.Flags().BoolVar(&backupSkipManifest, "skip-manifest", false, "Skip manifest generation (not recommended for production)")
 	backupCmd.Flags().StringVar(&backupFileMode, "file-mode", "default", `File permissions for backup and manifest files ("default"=0600, "system"=umask, or octal like "0640")`)
+	backupCmd.Flags().StringArrayVar(&backupExcludes, "exclude", nil, "Exclude paths matching glob pattern (repeatable, e.g. \"*.tmp\", \"node_modules\")")
+	backupCmd.Flags().StringArrayVar(&backupIncludes, "include", nil, "Only archive files matching glob pattern (repeatable)")
+	backupCmd.Flags().StringArrayVar(&backupExcludeFrom, "exclude-from", nil, "Read exclude patterns from file, one per line (repeatable)")
+	backupCmd.Flags().BoolVar(&backupExcludeCaches, "exclude-caches", false, "Skip contents of directories containing a valid CACHEDIR.TAG (the tag file is kept)")
+	backupCmd.Flags().BoolVar(&backupOneFileSystem, "one-file-system", false, "Do not descend into directories on other file systems (mount points are kept as empty directories)")
+	backupCmd.Flags().StringVar(&backupSpecialFiles, "special-files", archive.SpecialFilesStore, fmt.Sprintf("Handling of devices, FIFOs and sockets: %s (sockets are always skipped unless fail)", archive.SpecialFilePolicyNames()))
+	backupCmd.Flags().BoolVar(&backupFailOnChange, "fail-on-change", false, "Fail the backup if a file changes while it is being archived (default: record it in the manifest)")
+	backupCmd.Flags().BoolVar(&backupContinue, "continue-on-error", false, fmt.Sprintf("Skip unreadable files and record them in the manifest (exits with status %d)", ExitPartial))
+	backupCmd.Flags().BoolVar(&backupReproducible, "reproducible", false, "Produce a byte-identical archive for identical source trees and record its SHA256 in the manifest")
+	backupCmd.Flags().StringVar(&backupClampMtime, "clamp-mtime", "", "With --reproducible, store later modification times as this time (RFC 3339, YYYY-MM-DD, or @unix-seconds)")
+	backupCmd.Flags().StringVar(&backupReadAhead, "read-ahead", "0", "Memory budget for reading small files concurrently ahead of the archive writer, for slow file systems (0 disables)")
+	backupCmd.Flags().StringVar(&backupIgnoreFile, "ignore-file", archive.DefaultIgnoreFile, "Per-directory ignore file name with .gitignore syntax (empty string disables)")
 
 	backupCmd.MarkFlagRequired("source")
 	backupCmd.MarkFlagRequired("dest")
@@ -109,13 +141,49 @@ func runBackup(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Create compressor
+	// Parse compressor tuning
+	if backupThreads < 0 {
+		return common.InvalidConfig("--threads", fmt.Sprintf("%d is negative", backupThreads),
+			"Use 0 for the default, or the number of compression threads")
+	}
+	var zstdWindow int
+	if backupZstdWindow != "" {
+		size, err := common.ParseSize(backupZstdWindow)
+		if err != nil {
+			return common.InvalidConfig("--zstd-window", err.Error(), "Use a power of two such as 32MiB or 128MiB")
+		}
+		if size != 0 && compMethod != compress.Zstd {
+			return common.InvalidConfig("--zstd-window", "only applies to zstd compression",
+				"Add --compression zstd, or remove --zstd-window")
+		}
+		if size > compress.MaxZstdWindow {
+			return common.InvalidConfig("--zstd-window", fmt.Sprintf("%s is larger than %s", backupZstdWindow, common.Size(compress.MaxZstdWindow)),
+				"Use a power of two such as 32MiB or 128MiB")
+		}
+		zstdWindow = int(size)
+		if err := compress.ValidateZstdWindow(zstdWindow); err != nil {
+			return common.InvalidConfig("--zstd-window", err.Error(), "Use a power of two such as 32MiB or 128MiB")
+		}
+	}
+
+	// Create compressor (level 0 selects the method's default)
 	compressor, err := compress.NewCompressor(compress.Config{
-		Method: compMethod,
-		Level:  0, // Use default (level 6 for gzip, ignored for none)
+		Method:  compMethod,
+		Level:   backupCompLevel,
+		Threads: backupThreads,
+		Window:  zstdWindow,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create compressor: %w", err)
+		if compMethod == compress.None {
+			return common.InvalidConfig("--compression-level", err.Error(),
+				"Remove --compression-level, or choose a compression method")
+		}
+		return common.InvalidConfig("--compression-level", err.Error(),
+			fmt.Sprintf("Valid %s levels: %s", compMethod, compMethod.LevelRange()))
+	}
+	compLevel := backupCompLevel
+	if compLevel == 0 {
+		compLevel = compMethod.DefaultLevel()
 	}
 
 	// Parse encryption method
@@ -151,25 +219,66 @@ func runBackup(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Build include/exclude filter
+	filter, err := buildFilter(backupExcludes, backupIncludes, backupExcludeFrom)
+	if err != nil {
+		return err
+	}
+
+	// Parse special file policy
+	specialFiles, err := archive.ParseSpecialFilePolicy(backupSpecialFiles)
+	if err != nil {
+		return common.InvalidConfig("--special-files", err.Error(),
+			fmt.Sprintf("Use one of: %s", archive.SpecialFilePolicyNames()))
+	}
+
+	// Parse modification time clamp
+	clampMtime, err := parseClampMtime(backupClampMtime)
+	if err != nil {
+		return err
+	}
+	if !clampMtime.IsZero() && !backupReproducible {
+		return common.InvalidConfig("--clamp-mtime", "only applies to reproducible backups",
+			"Add --reproducible, or remove --clamp-mtime")
+	}
+
+	// Parse read-ahead memory budget
+	readAhead, err := common.ParseSize(backupReadAhead)
+	if err != nil {
+		return common.InvalidConfig("--read-ahead", err.Error(),
+			"Use a size such as 32MiB or 256K, or 0 to disable read-ahead")
+	}
+
 	// Execute backup
 	backupCfg := backup.Config{
-		SourcePath: backupSource,
-		DestDir:    backupDest,
-		Encryptor:  encryptor,
-		Compressor: compressor,
-		Verbose:    backupVerbose,
-		DryRun:     backupDryRun,
-		FileMode:   fileMode,
+		SourcePaths:      backupSources,
+		DestDir:          backupDest,
+		Encryptor:        encryptor,
+		Compressor:       compressor,
+		CompressionLevel: compLevel,
+		Verbose:          backupVerbose,
+		DryRun:           backupDryRun,
+		FileMode:         fileMode,
+		Filter:           filter,
+		IgnoreFile:       backupIgnoreFile,
+		ExcludeCaches:    backupExcludeCaches,
+		SpecialFiles:     specialFiles,
+		OneFileSystem:    backupOneFileSystem,
+		FailOnChange:     backupFailOnChange,
+		ContinueOnError:  backupContinue,
+		Reproducible:     backupReproducible,
+		ClampMtime:       clampMtime,
+		ReadAhead:        readAhead,
 	}
 
-	outputPath, uncompressedSize, err := backup.PerformBackup(ctx, backupCfg)
+	outputPath, result, err := backup.PerformBackup(ctx, backupCfg)
 	if err != nil {
 		return err // PerformBackup already returns user-friendly errors
 	}
 
 	// Generate manifest by default (unless dry-run or skip-manifest)
 	if !backupDryRun && !backupSkipManifest {
-		if err := generateManifest(outputPath, backupSource, uncompressedSize, backupVerbose, fileMode, compMethod.String(), encMethod.String()); err != nil {
+		if err := generateManifest(outputPath, backupCfg, result, compMethod.String(), encMethod.String()); err != nil {
 			// Warn but don't fail the backup
 			fmt.Fprintf(os.Stderr, "Warning: Failed to create manifest: %v\n", err)
 		}
@@ -177,8 +286,13 @@ func runBackup(cmd *cobra.Command, args []string) error {
 
 	// Silent by default - verbose output handled in backup package
 
+	// A partial backup must not push complete backups out of retention
+	if result.Partial() && backupRetention > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: skipping retention cleanup for partial backup\n")
+	}
+
 	// Apply retention policy if specified
-	if backupRetention > 0 {
+	if backupRetention > 0 && !result.Partial() {
 		retentionPolicy := retention.Policy{
 			KeepLast:  backupRetention,
 			BackupDir: backupDest,
@@ -193,16 +307,42 @@ func runBackup(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if result.Partial() {
+		return common.Wrap(ErrPartial,
+			fmt.Sprintf("Backup completed, but %d unreadable files were left out: %s", len(result.Errors), outputPath),
+			"The skipped files and their errors are listed above and in the manifest")
+	}
+
 	return nil
 }
 
 // generateManifest creates a manifest file for the backup
-func generateManifest(backupPath, sourcePath string, uncompressedSize int64, verbose bool, fileMode *os.FileMode, compressionName, encryptionName string) error {
+func generateManifest(backupPath string, cfg backup.Config, result backup.Result, compressionName, encryptionName string) error {
+	verbose := cfg.Verbose
+
 	// Create manifest
-	m, err := manifest.New(sourcePath, filepath.Base(backupPath), GetVersion(), compressionName, encryptionName)
+	sources := cfg.Sources()
+	m, err := manifest.New(manifest.SourceKey(sources), filepath.Base(backupPath), GetVersion(), compressionName, encryptionName)
 	if err != nil {
 		return fmt.Errorf("failed to create manifest: %w", err)
 	}
+	if len(sources) > 1 {
+		m.SourcePaths = sources
+	}
+	m.ExcludePatterns = cfg.Filter.Excludes
+	m.IncludePatterns = cfg.Filter.Includes
+	if cfg.Compressor.Type() != compress.None {
+		level := cfg.CompressionLevel
+		m.CompressionLevel = &level
+	}
+	m.SkippedMounts = result.SkippedMounts
+	m.ChangedFiles = result.ChangedFiles
+	m.VanishedFiles = result.VanishedFiles
+	m.Reproducible = cfg.Reproducible
+	m.TarChecksum = result.TarChecksum
+	for _, fe := range result.Errors {
+		m.Errors = append(m.Errors, manifest.FileError{Path: fe.Path, Error: fe.Err.Error()})
+	}
 
 	// Compute checksum
 	checksum, err := manifest.ComputeChecksumProgress(backupPath, progress.Config{
@@ -215,7 +355,7 @@ func generateManifest(backupPath, sourcePath string, uncompressedSize int64, ver
 	m.ChecksumValue = checksum
 
 	// Set size fields
-	m.UncompressedSizeBytes = uncompressedSize
+	m.UncompressedSizeBytes = result.UncompressedSize
 	info, err := os.Stat(backupPath)
 	if err == nil {
 		m.CompressedSizeBytes = info.Size()
@@ -223,7 +363,7 @@ func generateManifest(backupPath, sourcePath string, uncompressedSize int64, ver
 
 	// Write manifest
 	manifestPath := getManifestPath(backupPath)
-	if err := m.Write(manifestPath, fileMode); err != nil {
+	if err := m.Write(manifestPath, cfg.FileMode); err != nil {
 		return fmt.Errorf("failed to write manifest: %w", err)
 	}
 
@@ -234,11 +374,55 @@ func generateManifest(backupPath, sourcePath string, uncompressedSize int64, ver
 	return nil
 }
 
+// buildFilter combines --exclude, --include and --exclude-from into an archive filter
+func buildFilter(excludes, includes, excludeFrom []string) (archive.Filter, error) {
+	filter := archive.Filter{
+		Excludes: append([]string(nil), excludes...),
+		Includes: append([]string(nil), includes...),
+	}
+
+	for _, path := range excludeFrom {
+		patterns, err := archive.ReadPatternFile(path)
+		if err != nil {
+			return archive.Filter{}, common.Wrap(err, fmt.Sprintf("Cannot read exclude file: %s", path),
+				"Check that the --exclude-from file exists and is readable")
+		}
+		filter.Excludes = append(filter.Excludes, patterns...)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return archive.Filter{}, common.InvalidConfig("--exclude/--include", err.Error(),
+			"Use shell-style glob patterns such as \"*.tmp\" or \"data/cache/*\"")
+	}
+
+	return filter, nil
+}
+
 // getManifestPath returns the manifest path for a given backup file
 func getManifestPath(backupPath string) string {
 	return manifest.ManifestPath(backupPath)
 }
 
+// parseClampMtime parses the --clamp-mtime value: an RFC 3339 time, a date
+// (midnight UTC), or "@" followed by Unix seconds. Empty means no clamp.
+func parseClampMtime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if secs, ok := strings.CutPrefix(value, "@"); ok {
+		n, err := strconv.ParseInt(secs, 10, 64)
+		if err == nil {
+			return time.Unix(n, 0).UTC(), nil
+		}
+	} else if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	} else if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, common.InvalidConfig("--clamp-mtime", fmt.Sprintf("cannot parse %q", value),
+		"Use an RFC 3339 time (2026-01-02T15:04:05Z), a date (2026-01-02), or @unix-seconds")
+}
+
 // parseFileMode parses the --file-mode flag value.
 // Returns nil for "system" (use umask), or a concrete os.FileMode for "default" or an octal string.
 func parseFileMode(value string) (*os.FileMode, error) {
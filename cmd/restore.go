@@ -17,9 +17,13 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/icemarkom/secure-backup/internal/archive"
 	"github.com/icemarkom/secure-backup/internal/backup"
 	"github.com/icemarkom/secure-backup/internal/common"
 	"github.com/icemarkom/secure-backup/internal/compress"
@@ -41,6 +45,21 @@ var (
 	restoreDryRun         bool
 	restoreSkipManifest   bool
 	restoreForce          bool
+	restoreSameOwner      bool
+	restoreNumericOwner   bool
+	restoreMapUID         []string
+	restoreMapGID         []string
+	restoreXattrs         bool
+	restorePaths          []string
+	restoreIncludes       []string
+	restoreExcludes       []string
+	restoreStrip          int
+	restoreRelocate       []string
+	restoreOnConflict     string
+	restoreMirror         bool
+	restoreYes            bool
+	restoreAtomic         bool
+	restoreKeepPrev       bool
 )
 
 var restoreCmd = &cobra.Command{
@@ -80,6 +99,21 @@ Encryption methods:
 	restoreCmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "Preview restore without executing")
 	restoreCmd.Flags().BoolVar(&restoreSkipManifest, "skip-manifest", false, "Skip manifest validation (use for old backups without manifests)")
 	restoreCmd.Flags().BoolVar(&restoreForce, "force", false, "Allow restore to non-empty directory")
+	restoreCmd.Flags().BoolVar(&restoreSameOwner, "same-owner", os.Geteuid() == 0, "Restore file ownership from the archive (default: true when running as root)")
+	restoreCmd.Flags().BoolVar(&restoreNumericOwner, "numeric-owner", false, "Use archived numeric uid/gid, ignoring user and group names")
+	restoreCmd.Flags().StringArrayVar(&restoreMapUID, "map-uid", nil, "Remap archived UID to a local UID as OLD:NEW (repeatable)")
+	restoreCmd.Flags().StringArrayVar(&restoreMapGID, "map-gid", nil, "Remap archived GID to a local GID as OLD:NEW (repeatable)")
+	restoreCmd.Flags().BoolVar(&restoreXattrs, "xattrs", false, "Restore extended attributes (POSIX ACLs, SELinux labels, file capabilities)")
+	restoreCmd.Flags().StringArrayVar(&restorePaths, "path", nil, "Only restore this archive path and everything below it (repeatable, e.g. \"documents/report.pdf\")")
+	restoreCmd.Flags().StringArrayVar(&restoreIncludes, "include", nil, "Only restore files matching glob pattern (repeatable)")
+	restoreCmd.Flags().StringArrayVar(&restoreExcludes, "exclude", nil, "Skip archive paths matching glob pattern (repeatable)")
+	restoreCmd.Flags().IntVar(&restoreStrip, "strip-components", 0, "Remove N leading path components from archive paths (e.g. 1 drops the source directory name)")
+	restoreCmd.Flags().StringArrayVar(&restoreRelocate, "relocate", nil, "Restore archive path OLD under NEW, relative to --dest, as OLD=NEW (repeatable)")
+	restoreCmd.Flags().BoolVar(&restoreMirror, "mirror", false, "Remove files and directories under --dest that are not in the backup (asks for confirmation)")
+	restoreCmd.Flags().BoolVar(&restoreYes, "yes", false, "Do not ask for confirmation before --mirror removes files")
+	restoreCmd.Flags().BoolVar(&restoreAtomic, "atomic", false, "Extract into a staging directory next to --dest and swap it into place only if the whole restore succeeds")
+	restoreCmd.Flags().BoolVar(&restoreKeepPrev, "keep-prev", false, "With --atomic, keep the previous contents of --dest as <dest>.prev")
+	restoreCmd.Flags().StringVar(&restoreOnConflict, "on-conflict", archive.ConflictsOverwrite, fmt.Sprintf("Handling of files that already exist: %s (all but overwrite work without --force)", archive.ConflictPolicyNames()))
 
 	restoreCmd.MarkFlagRequired("file")
 	restoreCmd.MarkFlagRequired("dest")
@@ -159,15 +193,118 @@ func runRestore(cmd *cobra.Command, args []string) error {
 		return common.Wrap(err, "Failed to initialize decryption", hint)
 	}
 
+	// Parse ownership mappings
+	uidMap, err := archive.ParseIDMap(restoreMapUID)
+	if err != nil {
+		return common.InvalidConfig("--map-uid", err.Error(), "Use OLD:NEW numeric IDs, e.g. --map-uid 1000:2000")
+	}
+	gidMap, err := archive.ParseIDMap(restoreMapGID)
+	if err != nil {
+		return common.InvalidConfig("--map-gid", err.Error(), "Use OLD:NEW numeric IDs, e.g. --map-gid 1000:2000")
+	}
+
+	// Build selection for partial restores
+	filter := archive.Filter{Excludes: restoreExcludes, Includes: restoreIncludes}
+	if err := filter.Validate(); err != nil {
+		return common.InvalidConfig("--exclude/--include", err.Error(),
+			"Use shell-style glob patterns such as \"*.pdf\" or \"documents/*\"")
+	}
+	for _, p := range restorePaths {
+		if archive.CleanArchivePath(p) == "" {
+			return common.InvalidConfig("--path", fmt.Sprintf("%q selects the whole archive", p),
+				"Use an archive path such as documents/report.pdf, or omit --path")
+		}
+	}
+
+	// Parse path rewriting
+	if restoreStrip < 0 {
+		return common.InvalidConfig("--strip-components", fmt.Sprintf("%d is negative", restoreStrip),
+			"Use 0 (default) or a positive number of leading components to remove")
+	}
+	relocations, err := archive.ParseRelocations(restoreRelocate)
+	if err != nil {
+		return common.InvalidConfig("--relocate", err.Error(),
+			"Use OLD=NEW with a relative NEW path, e.g. --relocate documents=restored/documents")
+	}
+
+	// Parse conflict policy
+	onConflict, err := archive.ParseConflictPolicy(restoreOnConflict)
+	if err != nil {
+		return common.InvalidConfig("--on-conflict", err.Error(),
+			fmt.Sprintf("Use one of: %s", archive.ConflictPolicyNames()))
+	}
+
+	// Mirror restores delete files, so they need the whole backup and a confirmation
+	var confirm func([]string) (bool, error)
+	if restoreMirror {
+		if len(restorePaths) > 0 || !filter.IsEmpty() {
+			return common.InvalidConfig("--mirror", "cannot be combined with --path, --include or --exclude",
+				"Mirror the whole backup, or restore selected paths without --mirror")
+		}
+		if onConflict != archive.ConflictOverwrite {
+			return common.InvalidConfig("--mirror", fmt.Sprintf("cannot be combined with --on-conflict=%s", onConflict),
+				"Mirror restores always overwrite existing files; drop --on-conflict")
+		}
+		absDest, err := filepath.Abs(restoreDest)
+		if err != nil {
+			return common.Wrap(err, "Cannot resolve destination path", "Check the --dest path")
+		}
+		if filepath.Dir(absDest) == absDest {
+			return common.InvalidConfig("--mirror", "refusing to mirror into the filesystem root",
+				"Restore into the directory being rolled back, e.g. --dest /var/www")
+		}
+		if !restoreYes && !restoreDryRun {
+			if !isTerminal(os.Stdin) {
+				return common.InvalidConfig("--mirror", "confirmation required but standard input is not a terminal",
+					"Review the removals with --dry-run, then pass --yes")
+			}
+			confirm = confirmMirror
+		}
+	}
+
+	// Atomic restores replace the destination as a whole
+	if restoreKeepPrev && !restoreAtomic {
+		return common.InvalidConfig("--keep-prev", "only applies to atomic restores", "Add --atomic")
+	}
+	if restoreAtomic {
+		if restoreMirror {
+			return common.InvalidConfig("--atomic", "cannot be combined with --mirror",
+				"--atomic already replaces --dest with exactly the restored contents")
+		}
+		if onConflict != archive.ConflictOverwrite {
+			return common.InvalidConfig("--atomic", fmt.Sprintf("cannot be combined with --on-conflict=%s", onConflict),
+				"Atomic restores extract into an empty staging directory, so there are no conflicts")
+		}
+	}
+
 	// Execute restore
 	restoreCfg := backup.RestoreConfig{
-		BackupFile: restoreFile,
-		DestPath:   restoreDest,
-		Encryptor:  encryptor,
-		Compressor: compressor,
-		Verbose:    restoreVerbose,
-		DryRun:     restoreDryRun,
-		Force:      restoreForce,
+		BackupFile:   restoreFile,
+		DestPath:     restoreDest,
+		Encryptor:    encryptor,
+		Compressor:   compressor,
+		Verbose:      restoreVerbose,
+		DryRun:       restoreDryRun,
+		Force:        restoreForce,
+		SameOwner:    restoreSameOwner,
+		NumericOwner: restoreNumericOwner,
+		UIDMap:       uidMap,
+		GIDMap:       gidMap,
+		Xattrs:       restoreXattrs,
+		Devices:      os.Geteuid() == 0, // mknod of devices requires root
+		Paths:        restorePaths,
+		Filter:       filter,
+
+		StripComponents: restoreStrip,
+		Relocate:        relocations,
+
+		OnConflict: onConflict,
+
+		Mirror:  restoreMirror,
+		Confirm: confirm,
+
+		Atomic:       restoreAtomic,
+		KeepPrevious: restoreKeepPrev,
 	}
 
 	if err = backup.PerformRestore(ctx, restoreCfg); err != nil {
@@ -178,6 +315,43 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// maxConfirmPaths limits how many paths confirmMirror lists
+const maxConfirmPaths = 20
+
+// confirmMirror lists the paths a mirror restore will remove and asks to proceed
+func confirmMirror(paths []string) (bool, error) {
+	if len(paths) == 0 {
+		return true, nil
+	}
+
+	fmt.Printf("Mirror restore will remove %d paths not in the backup:\n", len(paths))
+	for i, p := range paths {
+		if i == maxConfirmPaths {
+			fmt.Printf("  ... and %d more (use --dry-run to list all)\n", len(paths)-maxConfirmPaths)
+			break
+		}
+		fmt.Printf("  %s\n", p)
+	}
+	fmt.Print("Continue? [y/N]: ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, common.Wrap(err, "Failed to read confirmation", "Pass --yes to skip the confirmation")
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 // validateManifest validates the manifest file for the backup
 func validateManifest(backupFile string, verbose bool) error {
 	manifestPath := manifest.ManifestPath(backupFile)
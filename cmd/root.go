@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -29,6 +30,15 @@ var (
 	appDate    = "unknown"
 )
 
+// Exit codes returned by ExitCode
+const (
+	ExitFailure = 1 // The command failed
+	ExitPartial = 3 // The backup was written, but unreadable files were left out
+)
+
+// ErrPartial marks errors of commands that completed with some files skipped
+var ErrPartial = errors.New("partial backup")
+
 var rootCmd = &cobra.Command{
 	Use:           "secure-backup",
 	Short:         "Secure, encrypted backups for any directory",
@@ -71,6 +81,14 @@ func ExecuteContext(ctx context.Context) error {
 	return rootCmd.ExecuteContext(ctx)
 }
 
+// ExitCode returns the process exit status for an error returned by Execute
+func ExitCode(err error) int {
+	if errors.Is(err, ErrPartial) {
+		return ExitPartial
+	}
+	return ExitFailure
+}
+
 func init() {
 	rootCmd.AddCommand(versionCmd)
 	// Note: other commands (backup, restore, verify, list) register themselves
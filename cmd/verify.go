@@ -94,10 +94,13 @@ func runVerify(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 
 	// Validate manifest (produces output — safe because flags are valid)
+	var tarChecksum string
 	if !verifySkipManifest && !verifyDryRun {
-		if _, err := validateAndDisplayManifest(verifyFile, verifyVerbose); err != nil {
+		m, err := validateAndDisplayManifest(verifyFile, verifyVerbose)
+		if err != nil {
 			return err
 		}
+		tarChecksum = m.TarChecksum
 	}
 
 	// For quick mode, we don't need encryptor/compressor
@@ -181,12 +184,13 @@ func runVerify(cmd *cobra.Command, args []string) error {
 
 	// Execute full verification
 	verifyCfg := backup.VerifyConfig{
-		BackupFile: verifyFile,
-		Encryptor:  encryptor,
-		Compressor: compressor,
-		Quick:      false,
-		Verbose:    verifyVerbose,
-		DryRun:     verifyDryRun,
+		BackupFile:  verifyFile,
+		Encryptor:   encryptor,
+		Compressor:  compressor,
+		Quick:       false,
+		Verbose:     verifyVerbose,
+		DryRun:      verifyDryRun,
+		TarChecksum: tarChecksum,
 	}
 
 	if err = backup.PerformVerify(ctx, verifyCfg); err != nil {
@@ -231,6 +235,9 @@ func validateAndDisplayManifest(backupFile string, verbose bool) (*manifest.Mani
 			fmt.Printf("Uncompressed size: %s\n", common.Size(m.UncompressedSizeBytes))
 		}
 		fmt.Printf("Compressed size:   %s\n", common.Size(m.CompressedSizeBytes))
+		if m.TarChecksum != "" {
+			fmt.Printf("Archive:  reproducible (SHA256: %s)\n", m.TarChecksum)
+		}
 	}
 
 	return m, nil
@@ -0,0 +1,107 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// errOutsideDest is returned when a path resolves outside the destination
+var errOutsideDest = errors.New("path escapes destination through a symlink")
+
+// maxSymlinks bounds symlink expansion in resolveBeneathWalk (matches Linux MAXSYMLINKS)
+const maxSymlinks = 40
+
+// checkBeneath verifies that rel, a cleaned path relative to root, resolves
+// inside root when symlinks already extracted below root are followed.
+//
+// Missing components are fine: they are created as real directories later.
+// Absolute symlinks are rejected even if they point back into root, matching
+// openat2 RESOLVE_BENEATH.
+func checkBeneath(root, rel string) error {
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == "" {
+		return nil
+	}
+	return resolveBeneath(root, rel)
+}
+
+// resolveBeneathWalk is the portable implementation of checkBeneath. It expands
+// symlinks one component at a time, tracking the resolved path lexically so
+// that ".." in a link target can never climb above root.
+func resolveBeneathWalk(root, rel string) error {
+	parts := strings.Split(rel, "/")
+	resolved := ""
+	links := 0
+
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if resolved == "" {
+				return errOutsideDest
+			}
+			resolved = path.Dir(resolved)
+			if resolved == "." {
+				resolved = ""
+			}
+			continue
+		}
+
+		next := path.Join(resolved, part)
+		info, err := os.Lstat(filepath.Join(root, filepath.FromSlash(next)))
+		if os.IsNotExist(err) {
+			return nil // The rest does not exist yet
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			if !info.IsDir() {
+				return nil // Creating anything below a file fails on its own
+			}
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return fmt.Errorf("too many levels of symbolic links")
+		}
+		target, err := os.Readlink(filepath.Join(root, filepath.FromSlash(next)))
+		if err != nil {
+			return err
+		}
+		if filepath.IsAbs(target) || filepath.VolumeName(target) != "" || strings.HasPrefix(filepath.ToSlash(target), "/") {
+			return errOutsideDest
+		}
+		// Continue with the link target in place of this component
+		parts = append(strings.Split(filepath.ToSlash(target), "/"), parts...)
+	}
+
+	return nil
+}
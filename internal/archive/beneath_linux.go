@@ -0,0 +1,61 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"errors"
+	"path"
+
+	"golang.org/x/sys/unix"
+)
+
+// resolveBeneath checks rel with openat2(RESOLVE_BENEATH), which lets the
+// kernel refuse any resolution that leaves root. Kernels without openat2
+// (before 5.6) or sandboxes that block it fall back to resolveBeneathWalk.
+func resolveBeneath(root, rel string) error {
+	rootFd, err := unix.Open(root, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(rootFd)
+
+	how := &unix.OpenHow{
+		Flags:   unix.O_PATH | unix.O_CLOEXEC,
+		Resolve: unix.RESOLVE_BENEATH | unix.RESOLVE_NO_MAGICLINKS,
+	}
+
+	// Missing components are created later; check the deepest existing ancestor
+	for p := rel; p != "."; p = path.Dir(p) {
+		fd, err := unix.Openat2(rootFd, p, how)
+		switch {
+		case err == nil:
+			unix.Close(fd)
+			return nil
+		case errors.Is(err, unix.EXDEV):
+			return errOutsideDest
+		case errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EPERM):
+			return resolveBeneathWalk(root, rel)
+		case errors.Is(err, unix.ENOENT):
+			continue
+		case errors.Is(err, unix.ENOTDIR):
+			return nil // Creating anything below a file fails on its own
+		default:
+			return err
+		}
+	}
+	return nil
+}
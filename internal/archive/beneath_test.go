@@ -0,0 +1,191 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createEscapeTree creates symlinks below root that point inside and outside it
+func createEscapeTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "in", "sub"), 0755))
+	require.NoError(t, os.Symlink(t.TempDir(), filepath.Join(root, "abs")))
+	require.NoError(t, os.Symlink("../outside", filepath.Join(root, "up")))
+	require.NoError(t, os.Symlink("in", filepath.Join(root, "ok")))
+	require.NoError(t, os.Symlink("ok/sub/..", filepath.Join(root, "chain")))
+	require.NoError(t, os.Symlink("in/../../x", filepath.Join(root, "deep")))
+	require.NoError(t, os.Symlink("loop", filepath.Join(root, "loop")))
+	return root
+}
+
+func TestResolveBeneath(t *testing.T) {
+	root := createEscapeTree(t)
+
+	tests := []struct {
+		rel  string
+		want string // "inside", "escape" or "error"
+	}{
+		{"in/file", "inside"},
+		{"in/sub", "inside"},
+		{"missing/a/b", "inside"},
+		{"ok/file", "inside"},
+		{"chain/file", "inside"},
+		{"abs/passwd", "escape"},
+		{"up/file", "escape"},
+		{"deep/file", "escape"},
+		{"loop/file", "error"},
+	}
+
+	impls := map[string]func(root, rel string) error{
+		"native": resolveBeneath,
+		"walk":   resolveBeneathWalk,
+	}
+	for name, resolve := range impls {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.rel, func(t *testing.T) {
+				err := resolve(root, tt.rel)
+				switch tt.want {
+				case "inside":
+					assert.NoError(t, err)
+				case "escape":
+					assert.ErrorIs(t, err, errOutsideDest)
+				default:
+					assert.Error(t, err)
+					assert.NotErrorIs(t, err, errOutsideDest)
+				}
+			})
+		}
+	}
+}
+
+// buildTar writes headers (and contents for regular files) into a tar archive
+func buildTar(t *testing.T, headers ...*tar.Header) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, h := range headers {
+		if h.Typeflag == tar.TypeReg {
+			h.Size = int64(len(h.Name))
+		}
+		require.NoError(t, tw.WriteHeader(h))
+		if h.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(h.Name))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func TestExtractTar_SymlinkEscape(t *testing.T) {
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret")
+	require.NoError(t, os.WriteFile(secret, []byte("secret"), 0600))
+
+	tests := []struct {
+		name    string
+		headers []*tar.Header
+	}{
+		{
+			name: "file through absolute symlink",
+			headers: []*tar.Header{
+				{Name: "data/link", Typeflag: tar.TypeSymlink, Linkname: outside, Mode: 0777},
+				{Name: "data/link/passwd", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+		},
+		{
+			name: "file through relative symlink",
+			headers: []*tar.Header{
+				{Name: "data/link", Typeflag: tar.TypeSymlink, Linkname: "../../" + filepath.Base(outside), Mode: 0777},
+				{Name: "data/link/passwd", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+		},
+		{
+			name: "directory over symlink",
+			headers: []*tar.Header{
+				{Name: "data/link", Typeflag: tar.TypeSymlink, Linkname: outside, Mode: 0777},
+				{Name: "data/link", Typeflag: tar.TypeDir, Mode: 0777},
+			},
+		},
+		{
+			name: "hard link through symlink",
+			headers: []*tar.Header{
+				{Name: "data/link", Typeflag: tar.TypeSymlink, Linkname: outside, Mode: 0777},
+				{Name: "data/copy", Typeflag: tar.TypeLink, Linkname: "data/link/secret"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Place the destination next to "outside" so the relative link reaches it
+			destDir := filepath.Join(filepath.Dir(outside), filepath.Base(outside)+"-dest")
+			t.Cleanup(func() { os.RemoveAll(destDir) })
+
+			err := ExtractTar(bytes.NewReader(buildTar(t, tt.headers...)), destDir, ExtractConfig{})
+			require.Error(t, err)
+			assert.ErrorIs(t, err, errOutsideDest)
+
+			entries, err := os.ReadDir(outside)
+			require.NoError(t, err)
+			assert.Len(t, entries, 1, "nothing may be written outside the destination")
+			info, err := os.Stat(outside)
+			require.NoError(t, err)
+			assert.NotEqual(t, os.FileMode(0777), info.Mode().Perm())
+		})
+	}
+}
+
+func TestExtractTar_ReplacesSymlink(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "target")
+	require.NoError(t, os.WriteFile(outside, []byte("original"), 0600))
+
+	destDir := t.TempDir()
+	data := buildTar(t,
+		&tar.Header{Name: "data/file", Typeflag: tar.TypeSymlink, Linkname: outside, Mode: 0777},
+		&tar.Header{Name: "data/file", Typeflag: tar.TypeReg, Mode: 0644},
+	)
+	require.NoError(t, ExtractTar(bytes.NewReader(data), destDir, ExtractConfig{}))
+
+	content, err := os.ReadFile(outside)
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(content), "symlink target must not be written")
+
+	info, err := os.Lstat(filepath.Join(destDir, "data", "file"))
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular())
+}
+
+func TestExtractTar_SymlinkInsideDestination(t *testing.T) {
+	destDir := t.TempDir()
+	data := buildTar(t,
+		&tar.Header{Name: "data/real/", Typeflag: tar.TypeDir, Mode: 0755},
+		&tar.Header{Name: "data/alias", Typeflag: tar.TypeSymlink, Linkname: "real", Mode: 0777},
+		&tar.Header{Name: "data/alias/file", Typeflag: tar.TypeReg, Mode: 0644},
+	)
+	require.NoError(t, ExtractTar(bytes.NewReader(data), destDir, ExtractConfig{}))
+	assert.FileExists(t, filepath.Join(destDir, "data", "real", "file"))
+}
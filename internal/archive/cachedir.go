@@ -0,0 +1,55 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// CacheDirTagName is the file name that marks a cache directory.
+// See https://bford.info/cachedir/ for the specification.
+const CacheDirTagName = "CACHEDIR.TAG"
+
+// cacheDirSignature is the header a CACHEDIR.TAG file must start with to be valid
+const cacheDirSignature = "Signature: 8a477f597d28d172789f06886806bc55"
+
+// isCacheDir reports whether dir contains a valid CACHEDIR.TAG.
+// The tag must be a regular file (not a symlink) starting with the standard signature.
+func isCacheDir(dir string) bool {
+	tagPath := filepath.Join(dir, CacheDirTagName)
+
+	info, err := os.Lstat(tagPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	f, err := os.Open(tagPath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	header := make([]byte, len(cacheDirSignature))
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false
+	}
+
+	return bytes.Equal(header, []byte(cacheDirSignature))
+}
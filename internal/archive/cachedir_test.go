@@ -0,0 +1,93 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validCacheDirTag = cacheDirSignature + "\n# This file is a cache directory tag.\n"
+
+func TestIsCacheDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"valid tag", validCacheDirTag, true},
+		{"signature only", cacheDirSignature, true},
+		{"wrong signature", "Signature: 0000000000000000000000000000000\n", false},
+		{"truncated", "Signature: 8a47", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, CacheDirTagName), []byte(tt.content), 0644))
+			assert.Equal(t, tt.want, isCacheDir(dir))
+		})
+	}
+
+	t.Run("no tag", func(t *testing.T) {
+		assert.False(t, isCacheDir(t.TempDir()))
+	})
+
+	t.Run("symlinked tag", func(t *testing.T) {
+		dir := t.TempDir()
+		target := filepath.Join(t.TempDir(), "tag")
+		require.NoError(t, os.WriteFile(target, []byte(validCacheDirTag), 0644))
+		require.NoError(t, os.Symlink(target, filepath.Join(dir, CacheDirTagName)))
+		assert.False(t, isCacheDir(dir))
+	})
+}
+
+func TestCreateTar_ExcludeCaches(t *testing.T) {
+	srcDir := t.TempDir()
+	base := filepath.Base(srcDir)
+
+	cacheDir := filepath.Join(srcDir, "cache")
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "blobs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, CacheDirTagName), []byte(validCacheDirTag), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "blobs", "big.bin"), []byte("cached data"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "data.txt"), []byte("data"), 0644))
+
+	var buf bytes.Buffer
+	bytesWritten, err := CreateTar(srcDir, &buf, CreateConfig{ExcludeCaches: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(validCacheDirTag)+len("data")), bytesWritten)
+
+	want := []string{
+		base,
+		filepath.Join(base, "cache"),
+		filepath.Join(base, "cache", CacheDirTagName),
+		filepath.Join(base, "data.txt"),
+	}
+	assert.Equal(t, want, listTarEntries(t, buf.Bytes()))
+
+	// Without --exclude-caches the cache contents are archived
+	buf.Reset()
+	_, err = CreateTar(srcDir, &buf, CreateConfig{})
+	require.NoError(t, err)
+	assert.Contains(t, listTarEntries(t, buf.Bytes()), filepath.Join(base, "cache", "blobs", "big.bin"))
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// copyFileData copies exactly size bytes of f to w. A file that grew since its
+// header was written is cut at size; one that shrank, or failed to read, is
+// padded with zeros. Either way the entry keeps the length its header
+// announced, so the archive stays readable. Returns the number of bytes read
+// from f; read failures are returned as *readError.
+func copyFileData(w io.Writer, f io.Reader, size int64, buf []byte) (int64, error) {
+	src := &sourceReader{r: f}
+	n, err := io.CopyBuffer(w, io.LimitReader(src, size), buf)
+	if err != nil && src.err == nil {
+		return n, err
+	}
+	if n < size {
+		if _, err := io.CopyBuffer(w, io.LimitReader(zeroReader{}, size-n), buf); err != nil {
+			return n, err
+		}
+	}
+	if src.err != nil {
+		return n, &readError{err: src.err}
+	}
+	return n, nil
+}
+
+// sourceReader remembers the first read error of r, to tell failures of the
+// file being archived apart from failures to write the archive
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil && err != io.EOF && s.err == nil {
+		s.err = err
+	}
+	return n, err
+}
+
+// zeroReader is an endless source of zero bytes
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// checkUnchanged compares a file after it was archived with before, the
+// FileInfo its header was built from. Changed files are recorded in the
+// result, or fail the archive with CreateConfig.FailOnChange.
+func (a *archiver) checkUnchanged(file string, f *os.File, before fs.FileInfo) error {
+	after, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s after reading: %w", file, err)
+	}
+	return a.recordChange(file, before, after)
+}
+
+// recordChange records file in the result if it changed between the before
+// and after stats, or fails the archive with CreateConfig.FailOnChange
+func (a *archiver) recordChange(file string, before, after fs.FileInfo) error {
+	if !fileChanged(before, after) {
+		return nil
+	}
+
+	if a.cfg.FailOnChange {
+		return fmt.Errorf("file changed while being archived: %s", file)
+	}
+	a.result.ChangedFiles = append(a.result.ChangedFiles, file)
+	return nil
+}
+
+// fileChanged reports whether size, modification time or change time differ
+func fileChanged(before, after fs.FileInfo) bool {
+	if before.Size() != after.Size() || !before.ModTime().Equal(after.ModTime()) {
+		return true
+	}
+	// archive/tar knows where each platform keeps the change time
+	bh, err1 := tar.FileInfoHeader(before, "")
+	ah, err2 := tar.FileInfoHeader(after, "")
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return !bh.ChangeTime.Equal(ah.ChangeTime)
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyFileData(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		size  int64
+		want  string
+	}{
+		{"exact", "hello", 5, "hello"},
+		{"grew", "hello world", 5, "hello"},
+		{"shrank", "hel", 5, "hel\x00\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			n, err := copyFileData(&out, bytes.NewReader([]byte(tt.input)), tt.size, make([]byte, 2))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, out.String())
+			assert.Equal(t, min(int64(len(tt.input)), tt.size), n)
+		})
+	}
+}
+
+func TestFileChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+
+	same, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.False(t, fileChanged(before, same))
+
+	later := before.ModTime().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.True(t, fileChanged(before, after))
+}
+
+// archiveChangedFile opens and stats path, runs modify, then archives the file
+// with a header from the earlier stat, as if it changed while being read.
+// Returns the archive and the archiver result.
+func archiveChangedFile(t *testing.T, path string, cfg CreateConfig, modify func()) ([]byte, Result, error) {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	before, err := f.Stat()
+	require.NoError(t, err)
+	header, err := tar.FileInfoHeader(before, "")
+	require.NoError(t, err)
+	header.Name = "file"
+	modify()
+
+	var buf bytes.Buffer
+	a := &archiver{w: &buf, tw: tar.NewWriter(&buf), cfg: cfg, links: make(map[fileID]string)}
+	err = a.writeFile(path, f, header, before, nil)
+	if err == nil {
+		require.NoError(t, a.tw.Close())
+	}
+	return buf.Bytes(), a.result, err
+}
+
+// readSingleEntry returns the content of the only entry of a tar archive
+func readSingleEntry(t *testing.T, data []byte) string {
+	t.Helper()
+	tr := tar.NewReader(bytes.NewReader(data))
+	_, err := tr.Next()
+	require.NoError(t, err)
+	content, err := io.ReadAll(tr)
+	require.NoError(t, err)
+	_, err = tr.Next()
+	assert.Equal(t, io.EOF, err, "archive must end cleanly")
+	return string(content)
+}
+
+func TestWriteFile_FileChanges(t *testing.T) {
+	t.Run("grew", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		require.NoError(t, os.WriteFile(path, []byte("line 1\n"), 0644))
+
+		data, result, err := archiveChangedFile(t, path, CreateConfig{}, func() {
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+			require.NoError(t, err)
+			_, err = f.WriteString("line 2\n")
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "line 1\n", readSingleEntry(t, data), "data past the header size is cut")
+		assert.Equal(t, []string{path}, result.ChangedFiles)
+	})
+
+	t.Run("shrank", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "db")
+		require.NoError(t, os.WriteFile(path, []byte("abcdef"), 0644))
+
+		data, result, err := archiveChangedFile(t, path, CreateConfig{}, func() {
+			require.NoError(t, os.Truncate(path, 2))
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "ab\x00\x00\x00\x00", readSingleEntry(t, data), "missing data is padded with zeros")
+		assert.Equal(t, []string{path}, result.ChangedFiles)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "static")
+		require.NoError(t, os.WriteFile(path, []byte("same"), 0644))
+
+		data, result, err := archiveChangedFile(t, path, CreateConfig{}, func() {})
+		require.NoError(t, err)
+		assert.Equal(t, "same", readSingleEntry(t, data))
+		assert.Empty(t, result.ChangedFiles)
+	})
+
+	t.Run("fail on change", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		require.NoError(t, os.WriteFile(path, []byte("line 1\n"), 0644))
+
+		_, _, err := archiveChangedFile(t, path, CreateConfig{FailOnChange: true}, func() {
+			require.NoError(t, os.WriteFile(path, []byte("rewritten\n"), 0644))
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "changed while being archived")
+	})
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ConflictPolicy controls what happens when an entry's target path already exists.
+type ConflictPolicy int
+
+const (
+	// ConflictOverwrite replaces existing files.
+	ConflictOverwrite ConflictPolicy = iota
+	// ConflictSkip keeps existing files and skips the archived entry.
+	ConflictSkip
+	// ConflictNewer replaces existing files only when the archived copy is newer.
+	ConflictNewer
+	// ConflictRename keeps existing files and restores the entry next to them.
+	ConflictRename
+	// ConflictFail aborts extraction at the first existing file.
+	ConflictFail
+)
+
+// String names for conflict policies, used in CLI flags.
+const (
+	ConflictsOverwrite = "overwrite"
+	ConflictsSkip      = "skip"
+	ConflictsNewer     = "newer"
+	ConflictsRename    = "rename"
+	ConflictsFail      = "fail"
+)
+
+// renameSuffix is appended to entries restored under ConflictRename
+const renameSuffix = ".restored"
+
+// String returns the CLI name of the policy.
+func (p ConflictPolicy) String() string {
+	switch p {
+	case ConflictOverwrite:
+		return ConflictsOverwrite
+	case ConflictSkip:
+		return ConflictsSkip
+	case ConflictNewer:
+		return ConflictsNewer
+	case ConflictRename:
+		return ConflictsRename
+	case ConflictFail:
+		return ConflictsFail
+	default:
+		return fmt.Sprintf("unknown(%d)", int(p))
+	}
+}
+
+// ConflictPolicyNames returns a comma-separated string of valid policy names.
+func ConflictPolicyNames() string {
+	return strings.Join([]string{ConflictsOverwrite, ConflictsSkip, ConflictsNewer, ConflictsRename, ConflictsFail}, ", ")
+}
+
+// ParseConflictPolicy converts a policy name to a ConflictPolicy.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch strings.ToLower(s) {
+	case ConflictsOverwrite:
+		return ConflictOverwrite, nil
+	case ConflictsSkip:
+		return ConflictSkip, nil
+	case ConflictsNewer:
+		return ConflictNewer, nil
+	case ConflictsRename:
+		return ConflictRename, nil
+	case ConflictsFail:
+		return ConflictFail, nil
+	default:
+		return 0, fmt.Errorf("unknown conflict policy: %s", s)
+	}
+}
+
+// conflictOutcome is how a written entry relates to the file that existed at
+// its destination
+type conflictOutcome int
+
+const (
+	outcomeCreated     conflictOutcome = iota // Nothing existed at the destination
+	outcomeOverwritten                        // The existing file was removed
+	outcomeRenamed                            // Written next to the existing file
+)
+
+// countWritten records an entry in the result once it has been written
+func (x *extractor) countWritten(outcome conflictOutcome) {
+	switch outcome {
+	case outcomeOverwritten:
+		x.result.Overwritten++
+	case outcomeRenamed:
+		x.result.Renamed++
+	default:
+		x.result.Created++
+	}
+}
+
+// resolveConflict applies the conflict policy to a non-directory entry. It
+// returns the path the entry should be written to, or "" to skip the entry,
+// and the outcome to count once the entry is written. Callers must only call
+// it for entries that will be written (see restorable): an existing file may
+// already be gone when it returns.
+//
+// Existing files are removed rather than truncated, so other hard links to
+// them are left alone and symlinks are replaced instead of written through.
+func (x *extractor) resolveConflict(header *tar.Header, targetPath string) (string, conflictOutcome, error) {
+	existing, err := os.Lstat(targetPath)
+	if os.IsNotExist(err) {
+		return targetPath, outcomeCreated, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to check existing file %s: %w", targetPath, err)
+	}
+
+	replace := false
+	switch x.cfg.OnConflict {
+	case ConflictOverwrite:
+		replace = true
+	case ConflictNewer:
+		replace = header.ModTime.After(existing.ModTime())
+	case ConflictRename:
+		renamed, err := availableName(targetPath)
+		if err != nil {
+			return "", 0, err
+		}
+		x.renamed[targetPath] = renamed
+		return renamed, outcomeRenamed, nil
+	case ConflictFail:
+		return "", 0, fmt.Errorf("refusing to overwrite existing file %s", targetPath)
+	}
+
+	if !replace {
+		x.result.Skipped++
+		return "", 0, nil
+	}
+	if existing.IsDir() {
+		return "", 0, fmt.Errorf("cannot replace directory %s with %s", targetPath, header.Name)
+	}
+	if err := os.Remove(targetPath); err != nil {
+		return "", 0, fmt.Errorf("failed to replace %s: %w", targetPath, err)
+	}
+	return targetPath, outcomeOverwritten, nil
+}
+
+// availableName returns the first unused path of the form
+// <path>.restored, <path>.restored.1, <path>.restored.2, ...
+func availableName(path string) (string, error) {
+	candidate := path + renameSuffix
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		} else if err != nil {
+			return "", fmt.Errorf("failed to check %s: %w", candidate, err)
+		}
+		candidate = fmt.Sprintf("%s%s.%d", path, renameSuffix, i)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConflictPolicy(t *testing.T) {
+	for _, p := range []ConflictPolicy{ConflictOverwrite, ConflictSkip, ConflictNewer, ConflictRename, ConflictFail} {
+		got, err := ParseConflictPolicy(p.String())
+		require.NoError(t, err)
+		assert.Equal(t, p, got)
+	}
+
+	_, err := ParseConflictPolicy("replace")
+	assert.Error(t, err)
+}
+
+func TestExtract_OnConflict(t *testing.T) {
+	archived := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	data := buildTar(t,
+		&tar.Header{Name: "data/", Typeflag: tar.TypeDir, Mode: 0700, ModTime: archived},
+		&tar.Header{Name: "data/old", Typeflag: tar.TypeReg, Mode: 0644, ModTime: archived},
+		&tar.Header{Name: "data/new", Typeflag: tar.TypeReg, Mode: 0644, ModTime: archived},
+		&tar.Header{Name: "data/lost", Typeflag: tar.TypeReg, Mode: 0644, ModTime: archived},
+	)
+
+	// setup creates "data/old" (older than the archive) and "data/new" (newer)
+	setup := func(t *testing.T) string {
+		destDir := t.TempDir()
+		dir := filepath.Join(destDir, "data")
+		require.NoError(t, os.Mkdir(dir, 0755))
+		for name, mtime := range map[string]time.Time{"old": archived.Add(-time.Hour), "new": archived.Add(time.Hour)} {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, []byte("live"), 0644))
+			require.NoError(t, os.Chtimes(path, mtime, mtime))
+		}
+		return destDir
+	}
+
+	tests := []struct {
+		policy  ConflictPolicy
+		old     string // Expected content of data/old
+		new     string // Expected content of data/new
+		created int
+		over    int
+		skipped int
+		renamed int
+	}{
+		{ConflictOverwrite, "data/old", "data/new", 1, 2, 0, 0},
+		{ConflictSkip, "live", "live", 1, 0, 2, 0},
+		{ConflictNewer, "data/old", "live", 1, 1, 1, 0},
+		{ConflictRename, "live", "live", 1, 0, 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			destDir := setup(t)
+			result, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{OnConflict: tt.policy})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.created, result.Created, "created")
+			assert.Equal(t, tt.over, result.Overwritten, "overwritten")
+			assert.Equal(t, tt.skipped, result.Skipped, "skipped")
+			assert.Equal(t, tt.renamed, result.Renamed, "renamed")
+
+			content, err := os.ReadFile(filepath.Join(destDir, "data", "old"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.old, string(content))
+			content, err = os.ReadFile(filepath.Join(destDir, "data", "new"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.new, string(content))
+			assert.FileExists(t, filepath.Join(destDir, "data", "lost"))
+
+			// Only overwrite touches the metadata of an existing directory
+			info, err := os.Stat(filepath.Join(destDir, "data"))
+			require.NoError(t, err)
+			if tt.policy == ConflictOverwrite {
+				assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+			} else {
+				assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+			}
+		})
+	}
+
+	t.Run("rename names", func(t *testing.T) {
+		destDir := setup(t)
+		for i := 0; i < 2; i++ {
+			_, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{OnConflict: ConflictRename})
+			require.NoError(t, err)
+		}
+		assert.FileExists(t, filepath.Join(destDir, "data", "old.restored"))
+		assert.FileExists(t, filepath.Join(destDir, "data", "old.restored.1"))
+		assert.FileExists(t, filepath.Join(destDir, "data", "lost.restored"))
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		destDir := setup(t)
+		_, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{OnConflict: ConflictFail})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "refusing to overwrite")
+
+		content, err := os.ReadFile(filepath.Join(destDir, "data", "old"))
+		require.NoError(t, err)
+		assert.Equal(t, "live", string(content))
+	})
+}
+
+func TestExtract_OnConflictRenameHardLink(t *testing.T) {
+	destDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(destDir, "data"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(destDir, "data", "file"), []byte("live"), 0644))
+
+	data := buildTar(t,
+		&tar.Header{Name: "data/file", Typeflag: tar.TypeReg, Mode: 0644},
+		&tar.Header{Name: "data/link", Typeflag: tar.TypeLink, Linkname: "data/file"},
+	)
+	_, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{OnConflict: ConflictRename})
+	require.NoError(t, err)
+
+	// The link points at the restored copy, not the existing file
+	content, err := os.ReadFile(filepath.Join(destDir, "data", "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "data/file", string(content))
+}
+
+func TestExtract_CreatedCountsWrittenEntries(t *testing.T) {
+	data := buildTar(t,
+		&tar.Header{Name: "data/file", Typeflag: tar.TypeReg, Mode: 0644},
+		&tar.Header{Name: "data/link", Typeflag: tar.TypeLink, Linkname: "data/file"},
+		&tar.Header{Name: "data/sym", Typeflag: tar.TypeSymlink, Linkname: "file"},
+		&tar.Header{Name: "data/cont", Typeflag: tar.TypeCont, Mode: 0644},
+	)
+
+	t.Run("all", func(t *testing.T) {
+		result, err := Extract(bytes.NewReader(data), t.TempDir(), ExtractConfig{})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Created, "unsupported entry types are not counted")
+	})
+
+	t.Run("hard link without its target", func(t *testing.T) {
+		destDir := t.TempDir()
+		result, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{Paths: []string{"data/link"}})
+		require.NoError(t, err)
+		_, err = os.Lstat(filepath.Join(destDir, "data", "link"))
+		assert.True(t, os.IsNotExist(err))
+		assert.Equal(t, 0, result.Created, "skipped hard links are not counted")
+	})
+}
+
+func TestExtract_SkippedEntriesKeepExistingFiles(t *testing.T) {
+	data := buildTar(t,
+		&tar.Header{Name: "data/file", Typeflag: tar.TypeReg, Mode: 0644},
+		&tar.Header{Name: "data/null", Typeflag: tar.TypeChar, Mode: 0666, Devmajor: 1, Devminor: 3},
+		&tar.Header{Name: "data/link", Typeflag: tar.TypeLink, Linkname: "data/file"},
+	)
+
+	for _, policy := range []ConflictPolicy{ConflictOverwrite, ConflictRename} {
+		t.Run(policy.String(), func(t *testing.T) {
+			destDir := t.TempDir()
+			dir := filepath.Join(destDir, "data")
+			require.NoError(t, os.Mkdir(dir, 0755))
+			for _, name := range []string{"null", "link"} {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("live"), 0644))
+			}
+
+			// The device is skipped without Devices, the hard link because
+			// its target is outside the selection
+			cfg := ExtractConfig{OnConflict: policy, Paths: []string{"data/null", "data/link"}}
+			result, err := Extract(bytes.NewReader(data), destDir, cfg)
+			require.NoError(t, err)
+
+			for _, name := range []string{"null", "link"} {
+				content, err := os.ReadFile(filepath.Join(dir, name))
+				require.NoError(t, err)
+				assert.Equal(t, "live", string(content), name)
+			}
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Len(t, entries, 2, "nothing is written next to existing files")
+			assert.Zero(t, result.Created, "created")
+			assert.Zero(t, result.Overwritten, "overwritten")
+			assert.Zero(t, result.Renamed, "renamed")
+		})
+	}
+}
+
+func TestExtract_OverwriteKeepsOtherHardLinks(t *testing.T) {
+	destDir := t.TempDir()
+	dir := filepath.Join(destDir, "data")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), []byte("live"), 0644))
+	require.NoError(t, os.Link(filepath.Join(dir, "file"), filepath.Join(destDir, "other")))
+
+	data := buildTar(t, &tar.Header{Name: "data/file", Typeflag: tar.TypeReg, Mode: 0644})
+	_, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(destDir, "other"))
+	require.NoError(t, err)
+	assert.Equal(t, "live", string(content), "existing files are replaced, not truncated")
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Filter selects archive entries using glob patterns matched against
+// archive-relative paths (e.g., "data/logs/app.log").
+//
+// Patterns without a slash match the entry's base name at any depth
+// ("*.tmp", "node_modules"). Patterns containing a slash match the full
+// archive-relative path ("data/cache/*"); a leading slash is ignored.
+//
+// Excludes always win. When Includes is non-empty, only non-directory entries
+// matching at least one include pattern are kept; directories are still
+// traversed so that matching files below them can be found.
+type Filter struct {
+	Excludes []string
+	Includes []string
+}
+
+// Validate checks that all patterns are syntactically valid globs
+func (f Filter) Validate() error {
+	for _, p := range f.Excludes {
+		if _, err := path.Match(normalizePattern(p), ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", p, err)
+		}
+	}
+	for _, p := range f.Includes {
+		if _, err := path.Match(normalizePattern(p), ""); err != nil {
+			return fmt.Errorf("invalid include pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// IsEmpty reports whether the filter has no patterns
+func (f Filter) IsEmpty() bool {
+	return len(f.Excludes) == 0 && len(f.Includes) == 0
+}
+
+// Excluded reports whether the entry at relPath should be left out of the archive.
+// isDir must be true for directories, which are never dropped by include patterns.
+func (f Filter) Excluded(relPath string, isDir bool) bool {
+	name := filepath.ToSlash(relPath)
+
+	for _, p := range f.Excludes {
+		if matchPattern(p, name) {
+			return true
+		}
+	}
+
+	if len(f.Includes) == 0 || isDir {
+		return false
+	}
+	for _, p := range f.Includes {
+		if matchPattern(p, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPattern matches a single glob pattern against a slash-separated path
+func matchPattern(pattern, name string) bool {
+	pattern = normalizePattern(pattern)
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(name)
+	}
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// normalizePattern converts a pattern to slash form and drops a leading slash
+func normalizePattern(pattern string) string {
+	return strings.TrimPrefix(filepath.ToSlash(pattern), "/")
+}
+
+// ReadPatternFile reads glob patterns from a file, one per line.
+// Blank lines and lines starting with '#' are ignored.
+func ReadPatternFile(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pattern file: %w", err)
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pattern file: %w", err)
+	}
+
+	return patterns, nil
+}
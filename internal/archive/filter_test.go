@@ -0,0 +1,74 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter_Excluded(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filter
+		relPath string
+		isDir   bool
+		want    bool
+	}{
+		{"empty filter", Filter{}, "data/file.txt", false, false},
+		{"basename glob", Filter{Excludes: []string{"*.tmp"}}, "data/sub/file.tmp", false, true},
+		{"basename glob no match", Filter{Excludes: []string{"*.tmp"}}, "data/sub/file.txt", false, false},
+		{"directory name", Filter{Excludes: []string{"node_modules"}}, "data/app/node_modules", true, true},
+		{"path pattern", Filter{Excludes: []string{"data/cache/*"}}, "data/cache/blob", false, true},
+		{"path pattern other dir", Filter{Excludes: []string{"data/cache/*"}}, "data/other/blob", false, false},
+		{"leading slash", Filter{Excludes: []string{"/data/cache"}}, "data/cache", true, true},
+		{"include match", Filter{Includes: []string{"*.go"}}, "data/main.go", false, false},
+		{"include no match", Filter{Includes: []string{"*.go"}}, "data/README.md", false, true},
+		{"include keeps directories", Filter{Includes: []string{"*.go"}}, "data/pkg", true, false},
+		{"exclude wins over include", Filter{Excludes: []string{"gen_*"}, Includes: []string{"*.go"}}, "data/gen_x.go", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filter.Excluded(tt.relPath, tt.isDir)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFilter_Validate(t *testing.T) {
+	assert.NoError(t, Filter{Excludes: []string{"*.tmp", "data/[a-z]*"}}.Validate())
+	assert.Error(t, Filter{Excludes: []string{"[unclosed"}}.Validate())
+	assert.Error(t, Filter{Includes: []string{"[unclosed"}}.Validate())
+}
+
+func TestReadPatternFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "excludes")
+	content := "# comment\n*.tmp\n\n  node_modules  \n#another\ncache/*\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	got, err := ReadPatternFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.tmp", "node_modules", "cache/*"}, got)
+
+	_, err = ReadPatternFile(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
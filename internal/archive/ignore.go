@@ -0,0 +1,191 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultIgnoreFile is the name of per-directory ignore files honored during archiving
+const DefaultIgnoreFile = ".backupignore"
+
+// ignoreRule is a single parsed line of an ignore file
+type ignoreRule struct {
+	segments []string // Slash-separated pattern segments ("**" matches any number of segments)
+	negate   bool     // Line started with '!': re-include matching entries
+	dirOnly  bool     // Line ended with '/': only match directories
+}
+
+// parseIgnoreRule parses one ignore file line using .gitignore syntax.
+// Returns false for blank lines and comments.
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	// Trailing spaces are ignored unless escaped with a backslash
+	line = strings.TrimRight(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	switch {
+	case strings.HasPrefix(line, "!"):
+		rule.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, "\\!"), strings.HasPrefix(line, "\\#"):
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to the ignore file's directory;
+	// otherwise the pattern matches at any depth below it.
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+// match reports whether the rule matches a slash-separated path relative to the
+// directory containing the ignore file
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	return matchSegments(r.segments, strings.Split(rel, "/"))
+}
+
+// matchSegments matches pattern segments against path segments with "**" support
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+
+	if pattern[0] == "**" {
+		// Trailing "**" matches everything inside, but not the directory itself
+		if len(pattern) == 1 {
+			return len(name) > 0
+		}
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(name) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// readIgnoreFile parses an ignore file. A missing file yields no rules.
+func readIgnoreFile(filePath string) ([]ignoreRule, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open ignore file %s: %w", filePath, err)
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ignore file %s: %w", filePath, err)
+	}
+
+	return rules, nil
+}
+
+// ignoreSet tracks the ignore rules loaded for each directory of a walk.
+// Directory keys are slash-separated paths relative to the walk root ("" = root).
+type ignoreSet struct {
+	fileName string
+	dirs     map[string][]ignoreRule
+}
+
+// newIgnoreSet creates an ignore set that reads files with the given name
+func newIgnoreSet(fileName string) *ignoreSet {
+	return &ignoreSet{
+		fileName: fileName,
+		dirs:     make(map[string][]ignoreRule),
+	}
+}
+
+// load reads the ignore file in absDir (if any) and attaches its rules to relDir
+func (s *ignoreSet) load(absDir, relDir string) error {
+	rules, err := readIgnoreFile(filepath.Join(absDir, s.fileName))
+	if err != nil {
+		return err
+	}
+	if len(rules) > 0 {
+		s.dirs[relDir] = rules
+	}
+	return nil
+}
+
+// ignored reports whether the entry at rel (relative to the walk root) is ignored.
+// Rules from deeper directories and later lines take precedence, as in .gitignore.
+func (s *ignoreSet) ignored(rel string, isDir bool) bool {
+	if len(s.dirs) == 0 {
+		return false
+	}
+
+	ignored := false
+	parts := strings.Split(rel, "/")
+	for depth := 0; depth < len(parts); depth++ {
+		dir := strings.Join(parts[:depth], "/")
+		rules, ok := s.dirs[dir]
+		if !ok {
+			continue
+		}
+		sub := strings.Join(parts[depth:], "/")
+		for _, rule := range rules {
+			if rule.match(sub, isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIgnoreRule(t *testing.T) {
+	tests := []struct {
+		line   string
+		wantOK bool
+		want   ignoreRule
+	}{
+		{"", false, ignoreRule{}},
+		{"# comment", false, ignoreRule{}},
+		{"*.log", true, ignoreRule{segments: []string{"**", "*.log"}}},
+		{"!keep.log", true, ignoreRule{segments: []string{"**", "keep.log"}, negate: true}},
+		{"build/", true, ignoreRule{segments: []string{"**", "build"}, dirOnly: true}},
+		{"/vendor", true, ignoreRule{segments: []string{"vendor"}}},
+		{"docs/**/*.pdf", true, ignoreRule{segments: []string{"docs", "**", "*.pdf"}}},
+		{"\\#literal", true, ignoreRule{segments: []string{"**", "#literal"}}},
+		{"trailing   ", true, ignoreRule{segments: []string{"**", "trailing"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := parseIgnoreRule(tt.line)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIgnoreSet_Ignored(t *testing.T) {
+	rules := func(lines ...string) []ignoreRule {
+		var out []ignoreRule
+		for _, l := range lines {
+			r, ok := parseIgnoreRule(l)
+			require.True(t, ok)
+			out = append(out, r)
+		}
+		return out
+	}
+
+	s := newIgnoreSet(DefaultIgnoreFile)
+	s.dirs[""] = rules("*.log", "!keep.log", "/build", "cache/", "docs/**/*.pdf", "tmp/**")
+	s.dirs["sub"] = rules("!*.log", "local")
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"deep/nested/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"src/build", true, false},
+		{"cache", true, true},
+		{"cache", false, false},
+		{"docs/a/b/manual.pdf", false, true},
+		{"docs/manual.pdf", false, true},
+		{"other/manual.pdf", false, false},
+		{"tmp", true, false},
+		{"tmp/scratch", false, true},
+		{"sub/app.log", false, false},
+		{"sub/local", false, true},
+		{"local", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.ignored(tt.rel, tt.isDir))
+		})
+	}
+}
+
+func TestCreateTar_IgnoreFile(t *testing.T) {
+	srcDir := t.TempDir()
+	base := filepath.Base(srcDir)
+
+	write := func(rel, content string) {
+		path := filepath.Join(srcDir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write(".backupignore", "*.log\nbuild/\n")
+	write("app.log", "log")
+	write("main.go", "go")
+	write("build/out.bin", "bin")
+	write("sub/.backupignore", "!important.log\n")
+	write("sub/important.log", "keep")
+	write("sub/debug.log", "drop")
+
+	var buf bytes.Buffer
+	_, err := CreateTar(srcDir, &buf, CreateConfig{IgnoreFile: DefaultIgnoreFile})
+	require.NoError(t, err)
+
+	want := []string{
+		base,
+		filepath.Join(base, ".backupignore"),
+		filepath.Join(base, "main.go"),
+		filepath.Join(base, "sub"),
+		filepath.Join(base, "sub", ".backupignore"),
+		filepath.Join(base, "sub", "important.log"),
+	}
+	assert.Equal(t, want, listTarEntries(t, buf.Bytes()))
+
+	// Disabled ignore files archive everything
+	buf.Reset()
+	_, err = CreateTar(srcDir, &buf, CreateConfig{})
+	require.NoError(t, err)
+	assert.Contains(t, listTarEntries(t, buf.Bytes()), filepath.Join(base, "app.log"))
+}
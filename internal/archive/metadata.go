@@ -0,0 +1,115 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// deferredDir is a directory whose mode and times are applied after extraction
+type deferredDir struct {
+	path   string
+	header *tar.Header
+}
+
+// applyMetadata restores ownership, mode, extended attributes and times of an
+// extracted entry. The order matters: chown clears setuid bits and file
+// capabilities, so mode and xattrs are applied after it. Directory modes and
+// times are deferred until the whole archive has been extracted.
+func (x *extractor) applyMetadata(header *tar.Header, path string) error {
+	// Restore ownership (uid/gid or user/group names)
+	if x.cfg.SameOwner {
+		if err := x.owners.chown(path, header); err != nil {
+			return err
+		}
+	}
+
+	// Symlink permissions are not meaningful; directory modes are deferred
+	if header.Typeflag != tar.TypeSymlink && header.Typeflag != tar.TypeDir {
+		if err := os.Chmod(path, headerMode(header)); err != nil {
+			return fmt.Errorf("failed to set mode of %s: %w", path, err)
+		}
+	}
+
+	// Restore extended attributes
+	if x.cfg.Xattrs {
+		if err := applyXattrs(header, path); err != nil {
+			return err
+		}
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		x.dirs = append(x.dirs, deferredDir{path: path, header: header})
+		return nil
+	case tar.TypeSymlink:
+		atime, mtime := headerTimes(header)
+		if err := lutimes(path, atime, mtime); err != nil {
+			return fmt.Errorf("failed to set times of symlink %s: %w", path, err)
+		}
+		return nil
+	default:
+		atime, mtime := headerTimes(header)
+		if err := os.Chtimes(path, atime, mtime); err != nil {
+			return fmt.Errorf("failed to set times of %s: %w", path, err)
+		}
+		return nil
+	}
+}
+
+// finishDirectories applies the archived mode and times to extracted directories,
+// deepest first. Deferring lets read-only directories be populated and keeps
+// directory mtimes from being bumped by the creation of their children.
+func (x *extractor) finishDirectories() error {
+	sort.SliceStable(x.dirs, func(i, j int) bool {
+		return strings.Count(x.dirs[i].path, string(os.PathSeparator)) >
+			strings.Count(x.dirs[j].path, string(os.PathSeparator))
+	})
+
+	for _, dir := range x.dirs {
+		if err := os.Chmod(dir.path, headerMode(dir.header)); err != nil {
+			return fmt.Errorf("failed to set mode of directory %s: %w", dir.path, err)
+		}
+		atime, mtime := headerTimes(dir.header)
+		if err := os.Chtimes(dir.path, atime, mtime); err != nil {
+			return fmt.Errorf("failed to set times of directory %s: %w", dir.path, err)
+		}
+	}
+
+	return nil
+}
+
+// headerMode returns the permission bits (including setuid, setgid and sticky) of a header
+func headerMode(header *tar.Header) os.FileMode {
+	return header.FileInfo().Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+}
+
+// headerTimes returns the access and modification times of a header.
+// Archives without an access time use the modification time for both.
+func headerTimes(header *tar.Header) (atime, mtime time.Time) {
+	mtime = header.ModTime
+	atime = header.AccessTime
+	if atime.IsZero() {
+		atime = mtime
+	}
+	return atime, mtime
+}
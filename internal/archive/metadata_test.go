@@ -0,0 +1,159 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderTimes(t *testing.T) {
+	mtime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	atime := mtime.Add(time.Hour)
+
+	gotA, gotM := headerTimes(&tar.Header{ModTime: mtime, AccessTime: atime})
+	assert.Equal(t, atime, gotA)
+	assert.Equal(t, mtime, gotM)
+
+	// Missing access time falls back to the modification time
+	gotA, gotM = headerTimes(&tar.Header{ModTime: mtime})
+	assert.Equal(t, mtime, gotA)
+	assert.Equal(t, mtime, gotM)
+}
+
+func TestExtractTar_RestoresTimes(t *testing.T) {
+	srcDir := t.TempDir()
+	base := filepath.Base(srcDir)
+
+	mtime := time.Date(2020, 6, 15, 12, 30, 45, 123456789, time.UTC)
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "sub", "file.txt"), []byte("data"), 0644))
+	require.NoError(t, os.Chtimes(filepath.Join(srcDir, "sub", "file.txt"), mtime, mtime))
+	require.NoError(t, os.Chtimes(filepath.Join(srcDir, "sub"), mtime, mtime))
+
+	var buf bytes.Buffer
+	_, err := CreateTar(srcDir, &buf, CreateConfig{})
+	require.NoError(t, err)
+
+	destDir := t.TempDir()
+	require.NoError(t, ExtractTar(&buf, destDir, ExtractConfig{}))
+
+	// Directory mtime survives the creation of its children
+	for _, rel := range []string{"sub", filepath.Join("sub", "file.txt")} {
+		info, err := os.Stat(filepath.Join(destDir, base, rel))
+		require.NoError(t, err)
+		assert.True(t, mtime.Equal(info.ModTime()), "%s: got %v, want %v", rel, info.ModTime(), mtime)
+	}
+}
+
+func TestExtractTar_ReadOnlyDirectory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directory permissions are not enforced on Windows")
+	}
+
+	srcDir := t.TempDir()
+	base := filepath.Base(srcDir)
+
+	roDir := filepath.Join(srcDir, "readonly")
+	require.NoError(t, os.MkdirAll(filepath.Join(roDir, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(roDir, "nested", "file.txt"), []byte("data"), 0644))
+	require.NoError(t, os.Chmod(filepath.Join(roDir, "nested"), 0555))
+	require.NoError(t, os.Chmod(roDir, 0555))
+	t.Cleanup(func() {
+		os.Chmod(filepath.Join(roDir, "nested"), 0755)
+		os.Chmod(roDir, 0755)
+	})
+
+	var buf bytes.Buffer
+	_, err := CreateTar(srcDir, &buf, CreateConfig{})
+	require.NoError(t, err)
+
+	destDir := t.TempDir()
+	require.NoError(t, ExtractTar(&buf, destDir, ExtractConfig{}))
+
+	extracted := filepath.Join(destDir, base, "readonly")
+	t.Cleanup(func() {
+		os.Chmod(filepath.Join(extracted, "nested"), 0755)
+		os.Chmod(extracted, 0755)
+	})
+
+	content, err := os.ReadFile(filepath.Join(extracted, "nested", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(content))
+
+	for _, dir := range []string{extracted, filepath.Join(extracted, "nested")} {
+		info, err := os.Stat(dir)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0555), info.Mode().Perm())
+	}
+}
+
+func TestExtractTar_MissingParentDirectories(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not enforced on Windows")
+	}
+
+	// Only the nested directory has an entry, as after --relocate or a selection
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "a/b/c/", Mode: 0750}))
+	require.NoError(t, tw.Close())
+
+	destDir := t.TempDir()
+	require.NoError(t, ExtractTar(&buf, destDir, ExtractConfig{}))
+
+	for _, dir := range []string{"a", "a/b"} {
+		info, err := os.Stat(filepath.Join(destDir, dir))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm(), dir)
+	}
+	info, err := os.Stat(filepath.Join(destDir, "a", "b", "c"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+}
+
+func TestExtractTar_SymlinkTimes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlink times are not restored on Windows")
+	}
+
+	mtime := time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     "link",
+		Linkname: "missing-target",
+		ModTime:  mtime,
+	}))
+	require.NoError(t, tw.Close())
+
+	destDir := t.TempDir()
+	require.NoError(t, ExtractTar(&buf, destDir, ExtractConfig{}))
+
+	info, err := os.Lstat(filepath.Join(destDir, "link"))
+	require.NoError(t, err)
+	assert.True(t, mtime.Equal(info.ModTime()), "got %v, want %v", info.ModTime(), mtime)
+}
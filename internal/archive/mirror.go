@@ -0,0 +1,71 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// recordTarget adds the destination path of an entry, and every directory
+// above it, to the mirror targets
+func (x *extractor) recordTarget(name string) {
+	prefix := x.destPath
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	for p := filepath.Join(x.destPath, name); strings.HasPrefix(p, prefix); p = filepath.Dir(p) {
+		if x.result.Targets[p] {
+			return // Parents were recorded with an earlier entry
+		}
+		x.result.Targets[p] = true
+	}
+}
+
+// Extraneous returns the paths under destPath that are not in targets, as
+// collected by Extract with ExtractConfig.Mirror. Directories are listed once,
+// without their contents. Symlinks are listed, never followed.
+func Extraneous(destPath string, targets map[string]bool) ([]string, error) {
+	absDestPath, err := filepath.Abs(destPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var extra []string
+	err = filepath.WalkDir(absDestPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == absDestPath && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipAll // Nothing to remove from a missing destination
+			}
+			return err
+		}
+		if path == absDestPath || targets[path] {
+			return nil
+		}
+		extra = append(extra, path)
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return extra, nil
+}
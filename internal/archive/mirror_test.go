@@ -0,0 +1,71 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtraneous(t *testing.T) {
+	data := createSelectionArchive(t)
+	destDir := t.TempDir()
+
+	// Files planted next to restored ones, including a whole directory and a symlink
+	outside := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(destDir, "data", "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(destDir, "data", "docs", "shell.php"), []byte("x"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(destDir, "data", "uploads", "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(destDir, "data", "uploads", "nested", "a"), []byte("x"), 0644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(destDir, "escape")))
+
+	result, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{Mirror: true})
+	require.NoError(t, err)
+
+	extra, err := Extraneous(destDir, result.Targets)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(destDir, "data", "docs", "shell.php"),
+		filepath.Join(destDir, "data", "uploads"),
+		filepath.Join(destDir, "escape"),
+	}, extra)
+}
+
+func TestExtraneous_MissingDestination(t *testing.T) {
+	extra, err := Extraneous(filepath.Join(t.TempDir(), "missing"), nil)
+	require.NoError(t, err)
+	assert.Empty(t, extra)
+}
+
+func TestExtract_ListOnly(t *testing.T) {
+	data := createSelectionArchive(t)
+	destDir := filepath.Join(t.TempDir(), "dest")
+
+	result, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{Mirror: true, ListOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Selected)
+	assert.True(t, result.Targets[filepath.Join(destDir, "data", "photos", "raw", "b.jpg")])
+	assert.True(t, result.Targets[filepath.Join(destDir, "data", "photos", "raw")])
+
+	_, err = os.Stat(destDir)
+	assert.True(t, os.IsNotExist(err), "list-only extraction must not create the destination")
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// ownerResolver maps archived ownership to local uid/gid values.
+// Name lookups are cached since archives typically repeat a handful of owners.
+type ownerResolver struct {
+	numeric bool
+	uidMap  map[int]int
+	gidMap  map[int]int
+	users   map[string]int // uname → uid (-1 = unknown locally)
+	groups  map[string]int // gname → gid (-1 = unknown locally)
+}
+
+// newOwnerResolver creates a resolver from the extraction config
+func newOwnerResolver(cfg ExtractConfig) *ownerResolver {
+	return &ownerResolver{
+		numeric: cfg.NumericOwner,
+		uidMap:  cfg.UIDMap,
+		gidMap:  cfg.GIDMap,
+		users:   make(map[string]int),
+		groups:  make(map[string]int),
+	}
+}
+
+// resolve returns the local uid and gid for a tar header.
+// Explicit ID maps win, then user/group names (unless numeric), then the archived IDs.
+func (o *ownerResolver) resolve(h *tar.Header) (uid, gid int) {
+	uid, gid = h.Uid, h.Gid
+
+	if mapped, ok := o.uidMap[h.Uid]; ok {
+		uid = mapped
+	} else if !o.numeric && h.Uname != "" {
+		if id := o.lookupUser(h.Uname); id >= 0 {
+			uid = id
+		}
+	}
+
+	if mapped, ok := o.gidMap[h.Gid]; ok {
+		gid = mapped
+	} else if !o.numeric && h.Gname != "" {
+		if id := o.lookupGroup(h.Gname); id >= 0 {
+			gid = id
+		}
+	}
+
+	return uid, gid
+}
+
+// lookupUser returns the local uid for a user name, or -1 if unknown
+func (o *ownerResolver) lookupUser(name string) int {
+	if id, ok := o.users[name]; ok {
+		return id
+	}
+	id := -1
+	if u, err := user.Lookup(name); err == nil {
+		if n, err := strconv.Atoi(u.Uid); err == nil {
+			id = n
+		}
+	}
+	o.users[name] = id
+	return id
+}
+
+// lookupGroup returns the local gid for a group name, or -1 if unknown
+func (o *ownerResolver) lookupGroup(name string) int {
+	if id, ok := o.groups[name]; ok {
+		return id
+	}
+	id := -1
+	if g, err := user.LookupGroup(name); err == nil {
+		if n, err := strconv.Atoi(g.Gid); err == nil {
+			id = n
+		}
+	}
+	o.groups[name] = id
+	return id
+}
+
+// chown applies the resolved ownership to path without following symlinks
+func (o *ownerResolver) chown(path string, h *tar.Header) error {
+	uid, gid := o.resolve(h)
+	if err := os.Lchown(path, uid, gid); err != nil {
+		return fmt.Errorf("failed to set ownership of %s to %d:%d: %w", path, uid, gid, err)
+	}
+	return nil
+}
+
+// ParseIDMap parses "OLD:NEW" ID mappings (e.g., "1000:2000") into a map
+func ParseIDMap(values []string) (map[int]int, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	m := make(map[int]int, len(values))
+	for _, v := range values {
+		oldStr, newStr, ok := strings.Cut(v, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid ID mapping %q: expected OLD:NEW", v)
+		}
+		oldID, err := strconv.Atoi(oldStr)
+		if err != nil || oldID < 0 {
+			return nil, fmt.Errorf("invalid ID mapping %q: %q is not a valid ID", v, oldStr)
+		}
+		newID, err := strconv.Atoi(newStr)
+		if err != nil || newID < 0 {
+			return nil, fmt.Errorf("invalid ID mapping %q: %q is not a valid ID", v, newStr)
+		}
+		m[oldID] = newID
+	}
+
+	return m, nil
+}
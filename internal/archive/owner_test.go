@@ -0,0 +1,100 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"os/user"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIDMap(t *testing.T) {
+	got, err := ParseIDMap([]string{"1000:2000", "0:65534"})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int{1000: 2000, 0: 65534}, got)
+
+	got, err = ParseIDMap(nil)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	for _, bad := range []string{"1000", "a:1", "1:b", "-1:5", "1:-5"} {
+		_, err := ParseIDMap([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestOwnerResolver_Resolve(t *testing.T) {
+	current, err := user.Current()
+	require.NoError(t, err)
+	currentUID, err := strconv.Atoi(current.Uid)
+	if err != nil {
+		t.Skip("Non-numeric user IDs on this platform")
+	}
+
+	tests := []struct {
+		name    string
+		cfg     ExtractConfig
+		header  tar.Header
+		wantUID int
+		wantGID int
+	}{
+		{
+			name:    "archived ids",
+			header:  tar.Header{Uid: 4242, Gid: 4343},
+			wantUID: 4242,
+			wantGID: 4343,
+		},
+		{
+			name:    "unknown names fall back to ids",
+			header:  tar.Header{Uid: 4242, Gid: 4343, Uname: "no-such-user-xyz", Gname: "no-such-group-xyz"},
+			wantUID: 4242,
+			wantGID: 4343,
+		},
+		{
+			name:    "known name wins over id",
+			header:  tar.Header{Uid: 4242, Gid: 4343, Uname: current.Username},
+			wantUID: currentUID,
+			wantGID: 4343,
+		},
+		{
+			name:    "numeric owner ignores names",
+			cfg:     ExtractConfig{NumericOwner: true},
+			header:  tar.Header{Uid: 4242, Gid: 4343, Uname: current.Username},
+			wantUID: 4242,
+			wantGID: 4343,
+		},
+		{
+			name:    "maps win over names",
+			cfg:     ExtractConfig{UIDMap: map[int]int{4242: 5000}, GIDMap: map[int]int{4343: 5001}},
+			header:  tar.Header{Uid: 4242, Gid: 4343, Uname: current.Username},
+			wantUID: 5000,
+			wantGID: 5001,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uid, gid := newOwnerResolver(tt.cfg).resolve(&tt.header)
+			assert.Equal(t, tt.wantUID, uid)
+			assert.Equal(t, tt.wantGID, gid)
+		})
+	}
+}
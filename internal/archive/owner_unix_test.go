@@ -0,0 +1,85 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unix
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractTar_SameOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("Ownership restore requires root")
+	}
+
+	// Build an archive with explicit, host-independent ownership
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "data", Mode: 0755, Uid: 4242, Gid: 4343}))
+	content := []byte("owned")
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "data/file.txt", Mode: 0644, Size: int64(len(content)), Uid: 4242, Gid: 4343}))
+	_, err := tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "data/link", Linkname: "file.txt", Uid: 4242, Gid: 4343}))
+	require.NoError(t, tw.Close())
+	data := buf.Bytes()
+
+	ownerOf := func(path string) (int, int) {
+		info, err := os.Lstat(path)
+		require.NoError(t, err)
+		st := info.Sys().(*syscall.Stat_t)
+		return int(st.Uid), int(st.Gid)
+	}
+
+	t.Run("same owner", func(t *testing.T) {
+		destDir := t.TempDir()
+		require.NoError(t, ExtractTar(bytes.NewReader(data), destDir, ExtractConfig{SameOwner: true}))
+		for _, name := range []string{"data", "data/file.txt", "data/link"} {
+			uid, gid := ownerOf(filepath.Join(destDir, name))
+			assert.Equal(t, 4242, uid, name)
+			assert.Equal(t, 4343, gid, name)
+		}
+	})
+
+	t.Run("mapped owner", func(t *testing.T) {
+		destDir := t.TempDir()
+		require.NoError(t, ExtractTar(bytes.NewReader(data), destDir, ExtractConfig{
+			SameOwner: true,
+			UIDMap:    map[int]int{4242: 5000},
+			GIDMap:    map[int]int{4343: 5001},
+		}))
+		uid, gid := ownerOf(filepath.Join(destDir, "data", "file.txt"))
+		assert.Equal(t, 5000, uid)
+		assert.Equal(t, 5001, gid)
+	})
+
+	t.Run("ownership not restored by default", func(t *testing.T) {
+		destDir := t.TempDir()
+		require.NoError(t, ExtractTar(bytes.NewReader(data), destDir, ExtractConfig{}))
+		uid, _ := ownerOf(filepath.Join(destDir, "data", "file.txt"))
+		assert.Equal(t, 0, uid)
+	})
+}
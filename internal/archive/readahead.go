@@ -0,0 +1,198 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// Read-ahead tuning
+const (
+	readAheadWorkers = 8       // Files opened and read concurrently
+	readAheadMaxFile = 1 << 20 // Larger files are streamed by the writer
+	readAheadQueue   = 1024    // Files read ahead at most, whatever their size
+)
+
+// readAhead reads upcoming small files into memory on a pool of workers, so
+// per-file open and read latency overlaps with writing the archive. The
+// archive is still written in scan order: WriteTar takes each file from next
+// when it reaches the entry. Files read but not yet written stay within the
+// CreateConfig.ReadAhead memory budget.
+type readAhead struct {
+	budget    int64
+	sem       *semaphore.Weighted
+	queue     chan *prefetch // Files in scan order, as handed to the workers
+	sparseOff bool           // Reproducible archives do not detect holes
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// prefetch is one file read ahead. data is nil when the writer must read the
+// file itself: on any error, for sparse files, and for files that grew past
+// the budget reserved at scan time.
+type prefetch struct {
+	path  string
+	size  int64 // Budget held until the entry is written
+	done  chan struct{}
+	info  fs.FileInfo // Stat when opened; the header is built from it
+	after fs.FileInfo // Stat after reading, for change detection
+	data  []byte      // Exactly info.Size() bytes, zero-padded if the file shrank
+	n     int64       // Bytes read from the file
+}
+
+// readAheadCandidate reports whether an entry is read ahead: small regular
+// files with a single link (hard links are resolved by the writer)
+func readAheadCandidate(e Entry, budget int64) bool {
+	if !e.Mode.IsRegular() || e.Links > 1 {
+		return false
+	}
+	return e.Size > 0 && e.Size <= readAheadMaxFile && e.Size <= budget
+}
+
+// newReadAhead starts reading ahead the candidate entries of a scan. Returns nil
+// if read-ahead is disabled; all methods accept a nil receiver.
+func newReadAhead(scan *Scan) *readAhead {
+	cfg := scan.cfg
+	if cfg.ReadAhead <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &readAhead{
+		budget:    cfg.ReadAhead,
+		sem:       semaphore.NewWeighted(cfg.ReadAhead),
+		queue:     make(chan *prefetch, readAheadQueue),
+		sparseOff: cfg.Reproducible,
+		cancel:    cancel,
+	}
+
+	jobs := make(chan *prefetch)
+	r.wg.Add(readAheadWorkers + 1)
+	for range readAheadWorkers {
+		go func() {
+			defer r.wg.Done()
+			for p := range jobs {
+				r.read(p)
+				close(p.done)
+			}
+		}()
+	}
+
+	// Hand out candidates in scan order while the budget allows
+	go func() {
+		defer r.wg.Done()
+		defer close(jobs)
+		defer close(r.queue)
+		for _, e := range scan.Entries {
+			if !readAheadCandidate(e, r.budget) {
+				continue
+			}
+			p := &prefetch{path: scan.Path(e), size: e.Size, done: make(chan struct{})}
+			if err := r.sem.Acquire(ctx, p.size); err != nil {
+				return
+			}
+			select {
+			case r.queue <- p:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- p:
+			case <-ctx.Done():
+				close(p.done)
+				return
+			}
+		}
+	}()
+
+	return r
+}
+
+// read reads one file into memory. Anything unusual leaves p.data nil, and
+// the writer then handles the file (and reports its errors) as usual.
+func (r *readAhead) read(p *prefetch) {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() || info.Size() > p.size {
+		return
+	}
+	if !r.sparseOff {
+		regions, err := dataRegions(f, info)
+		if err != nil || isSparse(regions, info.Size()) {
+			return
+		}
+	}
+
+	// Like copyFileData: cut at the opened size, zero-padded if the file shrank
+	data := make([]byte, info.Size())
+	n, err := io.ReadFull(f, data)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return
+	}
+	after, err := f.Stat()
+	if err != nil {
+		return
+	}
+
+	p.info, p.after, p.data, p.n = info, after, data, int64(n)
+}
+
+// next returns entry e read ahead, or nil if the writer must read it itself
+func (r *readAhead) next(e Entry) *prefetch {
+	if r == nil || !readAheadCandidate(e, r.budget) {
+		return nil
+	}
+	p, ok := <-r.queue
+	if !ok {
+		return nil
+	}
+	<-p.done
+	if p.data == nil {
+		r.release(p)
+		return nil
+	}
+	return p
+}
+
+// release returns the memory of a written file to the budget
+func (r *readAhead) release(p *prefetch) {
+	if r == nil || p == nil {
+		return
+	}
+	p.data = nil
+	r.sem.Release(p.size)
+}
+
+// close stops reading ahead and waits for the workers to finish
+func (r *readAhead) close() {
+	if r == nil {
+		return
+	}
+	r.cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// benchTree creates a tree of many small files, the case read-ahead is for.
+// Files hold pseudo-random data so the page cache and copies see real contents
+// rather than zero pages.
+func benchTree(b *testing.B) (string, int64) {
+	b.Helper()
+	src := b.TempDir()
+	rng := rand.NewChaCha8([32]byte{})
+	var total int64
+	for d := range 20 {
+		dir := filepath.Join(src, fmt.Sprintf("dir%02d", d))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			b.Fatalf("Mkdir: %v", err)
+		}
+		for f := range 100 {
+			size := 1024 + (d*100+f)%16*1024 // 1 KB to 16 KB
+			data := make([]byte, size)
+			rng.Read(data)
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%03d", f)), data, 0644); err != nil {
+				b.Fatalf("WriteFile: %v", err)
+			}
+			total += int64(size)
+		}
+	}
+	return src, total
+}
+
+// benchCreateTar benchmarks archiving the small-file tree with a read-ahead budget.
+func benchCreateTar(b *testing.B, readAhead int64) {
+	src, total := benchTree(b)
+
+	b.SetBytes(total)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := CreateTar(src, io.Discard, CreateConfig{ReadAhead: readAhead}); err != nil {
+			b.Fatalf("CreateTar: %v", err)
+		}
+	}
+}
+
+func BenchmarkCreateTar_Sequential(b *testing.B)    { benchCreateTar(b, 0) }
+func BenchmarkCreateTar_ReadAhead1MB(b *testing.B)  { benchCreateTar(b, 1<<20) }
+func BenchmarkCreateTar_ReadAhead32MB(b *testing.B) { benchCreateTar(b, 32<<20) }
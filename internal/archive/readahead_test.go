@@ -0,0 +1,191 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAheadCandidate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+		return path
+	}
+	small := write("small", 100)
+	empty := write("empty", 0)
+	large := write("large", readAheadMaxFile+1)
+	linked := write("linked", 100)
+	require.NoError(t, os.Link(linked, filepath.Join(dir, "linked2")))
+
+	entry := func(path string) Entry {
+		info, err := os.Lstat(path)
+		require.NoError(t, err)
+		s := &scanner{scan: &Scan{bases: []string{dir}}}
+		s.add(filepath.Base(path), info)
+		return s.scan.Entries[0]
+	}
+
+	assert.True(t, readAheadCandidate(entry(small), 1<<20))
+	assert.False(t, readAheadCandidate(entry(small), 50), "larger than the whole budget")
+	assert.False(t, readAheadCandidate(entry(empty), 1<<20), "nothing to read")
+	assert.False(t, readAheadCandidate(entry(large), 1<<30), "larger files are streamed")
+	assert.False(t, readAheadCandidate(entry(dir), 1<<20), "not a regular file")
+	if entry(linked).Links > 0 {
+		assert.False(t, readAheadCandidate(entry(linked), 1<<20), "hard links are resolved by the writer")
+	}
+}
+
+func TestWriteTar_ReadAhead(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "data")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0755))
+	for i := range 50 {
+		content := strings.Repeat(string(rune('a'+i%26)), 100*(i+1))
+		require.NoError(t, os.WriteFile(filepath.Join(src, "sub", "f"+string(rune('a'+i%26))+strings.Repeat("x", i/26)), []byte(content), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(src, "large.bin"), bytes.Repeat([]byte("L"), readAheadMaxFile+10), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "hard1"), []byte("shared"), 0644))
+	require.NoError(t, os.Link(filepath.Join(src, "hard1"), filepath.Join(src, "hard2")))
+	require.NoError(t, os.Symlink("hard1", filepath.Join(src, "link")))
+
+	// Access times change as files are read, so compare reproducible archives
+	archive := func(readAhead int64) ([]byte, Result) {
+		var buf bytes.Buffer
+		scan, err := ScanSources([]string{src}, CreateConfig{Reproducible: true, ReadAhead: readAhead})
+		require.NoError(t, err)
+		result, err := WriteTar(&buf, scan)
+		require.NoError(t, err)
+		return buf.Bytes(), result
+	}
+
+	sequential, want := archive(0)
+	// A budget smaller than most files makes the workers wait for the writer
+	for _, budget := range []int64{1024, 64 << 10, 32 << 20} {
+		data, result := archive(budget)
+		assert.Equal(t, sequential, data, "budget %d", budget)
+		assert.Equal(t, want, result, "budget %d", budget)
+	}
+}
+
+func TestWriteTar_ReadAheadChangedSinceScan(t *testing.T) {
+	src := t.TempDir()
+	base := filepath.Base(src)
+	grown := filepath.Join(src, "grown.log")
+	gone := filepath.Join(src, "gone.txt")
+	require.NoError(t, os.WriteFile(grown, []byte("line 1\n"), 0644))
+	require.NoError(t, os.WriteFile(gone, []byte("temporary"), 0644))
+
+	scan, err := ScanSources([]string{src}, CreateConfig{ReadAhead: 1 << 20})
+	require.NoError(t, err)
+
+	// A file that grew past its reservation is read by the writer instead
+	require.NoError(t, os.WriteFile(grown, []byte("line 1\nline 2\n"), 0644))
+	require.NoError(t, os.Remove(gone))
+
+	var buf bytes.Buffer
+	result, err := WriteTar(&buf, scan)
+	require.NoError(t, err)
+	assert.Equal(t, []string{gone}, result.VanishedFiles)
+	assert.Empty(t, result.ChangedFiles)
+	assert.Equal(t, int64(len("line 1\nline 2\n")), result.BytesWritten)
+
+	tr := tar.NewReader(&buf)
+	contents := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		contents[header.Name] = string(data)
+	}
+	assert.Equal(t, map[string]string{
+		base:                             "",
+		filepath.Join(base, "grown.log"): "line 1\nline 2\n",
+	}, contents)
+}
+
+func TestWriteBuffered_FileChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("line 1\n"), 0644))
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+	later := before.ModTime().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+
+	pf := &prefetch{path: path, info: before, after: after, data: []byte("line 1\n"), n: 7}
+	header, err := tar.FileInfoHeader(before, "")
+	require.NoError(t, err)
+	header.Name = "file"
+
+	var buf bytes.Buffer
+	a := &archiver{w: &buf, tw: tar.NewWriter(&buf), links: make(map[fileID]string)}
+	require.NoError(t, a.writeBuffered(path, header, pf))
+	require.NoError(t, a.tw.Close())
+	assert.Equal(t, "line 1\n", readSingleEntry(t, buf.Bytes()))
+	assert.Equal(t, []string{path}, a.result.ChangedFiles)
+	assert.Equal(t, int64(7), a.result.BytesWritten)
+
+	buf.Reset()
+	a = &archiver{w: &buf, tw: tar.NewWriter(&buf), cfg: CreateConfig{FailOnChange: true}, links: make(map[fileID]string)}
+	err = a.writeBuffered(path, header, pf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "changed while being archived")
+}
+
+// limitedWriter fails once more than n bytes were written
+type limitedWriter struct {
+	n int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		return 0, errors.New("disk full")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteTar_ReadAheadWriteError(t *testing.T) {
+	src := t.TempDir()
+	for i := range 200 {
+		require.NoError(t, os.WriteFile(filepath.Join(src, strings.Repeat("f", i+1)), make([]byte, 512), 0644))
+	}
+	scan, err := ScanSources([]string{src}, CreateConfig{ReadAhead: 4096})
+	require.NoError(t, err)
+
+	// The workers stop when the writer fails part way through
+	_, err = WriteTar(&limitedWriter{n: 16 << 10}, scan)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// Relocation moves an archive subtree to a different path on restore
+type Relocation struct {
+	Old string // Archive path prefix (e.g., "home/alice")
+	New string // Replacement relative to the destination ("" = destination root)
+}
+
+// ParseRelocations parses "OLD=NEW" relocations (e.g., "documents=docs/2026")
+func ParseRelocations(values []string) ([]Relocation, error) {
+	var rules []Relocation
+	for _, v := range values {
+		oldPath, newPath, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid relocation %q: expected OLD=NEW", v)
+		}
+		oldPath = CleanArchivePath(oldPath)
+		if oldPath == "" {
+			return nil, fmt.Errorf("invalid relocation %q: OLD must name an archive path", v)
+		}
+		if err := validateTarPath(newPath); err != nil {
+			return nil, fmt.Errorf("invalid relocation %q: %w", v, err)
+		}
+		newPath = path.Clean(newPath)
+		if newPath == "." {
+			newPath = ""
+		}
+		rules = append(rules, Relocation{Old: oldPath, New: newPath})
+	}
+	return rules, nil
+}
+
+// rewriteHeader applies StripComponents and Relocate to an entry's name and,
+// for hard links, its link target. It returns false when the entry is stripped
+// away entirely and should be skipped.
+//
+// The rewritten names are not cleaned here: extractEntry validates them, so an
+// entry whose rewritten name escapes the destination is still rejected.
+func (x *extractor) rewriteHeader(header *tar.Header) bool {
+	if x.cfg.StripComponents == 0 && len(x.cfg.Relocate) == 0 {
+		return true
+	}
+
+	header.Name = x.rewriteName(header.Name)
+	if header.Name == "" {
+		return false
+	}
+
+	if header.Typeflag == tar.TypeLink {
+		target := x.rewriteName(header.Linkname)
+		if target == "" {
+			fmt.Fprintf(os.Stderr, "Warning: skipping hard link %s: target %s was stripped\n", header.Name, header.Linkname)
+			return false
+		}
+		header.Linkname = target
+	}
+	return true
+}
+
+// rewriteName strips leading components, then applies the longest matching relocation
+func (x *extractor) rewriteName(name string) string {
+	for i := 0; i < x.cfg.StripComponents; i++ {
+		j := strings.IndexByte(name, '/')
+		if j < 0 {
+			return ""
+		}
+		name = strings.TrimLeft(name[j+1:], "/")
+	}
+	if name == "" {
+		return ""
+	}
+
+	var match *Relocation
+	for i, rule := range x.cfg.Relocate {
+		trimmed := strings.TrimSuffix(name, "/")
+		if trimmed != rule.Old && !strings.HasPrefix(trimmed, rule.Old+"/") {
+			continue
+		}
+		if match == nil || len(rule.Old) > len(match.Old) {
+			match = &x.cfg.Relocate[i]
+		}
+	}
+	if match == nil {
+		return name
+	}
+
+	rest := strings.TrimPrefix(name, match.Old)
+	rest = strings.TrimLeft(rest, "/")
+	switch {
+	case match.New == "":
+		return rest
+	case rest == "":
+		return match.New
+	default:
+		return match.New + "/" + rest
+	}
+}
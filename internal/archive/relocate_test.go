@@ -0,0 +1,130 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRelocations(t *testing.T) {
+	got, err := ParseRelocations([]string{"data/docs=restored/docs", "/data/=.", "photos/=pictures/"})
+	require.NoError(t, err)
+	assert.Equal(t, []Relocation{
+		{Old: "data/docs", New: "restored/docs"},
+		{Old: "data", New: ""},
+		{Old: "photos", New: "pictures"},
+	}, got)
+
+	for _, value := range []string{"data", "=docs", "/=docs", "data=../outside", "data=/etc"} {
+		t.Run(value, func(t *testing.T) {
+			_, err := ParseRelocations([]string{value})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRewriteName(t *testing.T) {
+	relocate := []Relocation{
+		{Old: "data", New: "restored"},
+		{Old: "data/photos", New: "pictures"},
+		{Old: "home", New: ""},
+	}
+
+	tests := []struct {
+		name  string
+		strip int
+		rules []Relocation
+		input string
+		want  string
+	}{
+		{"unchanged", 0, nil, "data/docs/a.txt", "data/docs/a.txt"},
+		{"strip one", 1, nil, "data/docs/a.txt", "docs/a.txt"},
+		{"strip top directory", 1, nil, "data/", ""},
+		{"strip too many", 3, nil, "data/docs/a.txt", ""},
+		{"relocate", 0, relocate, "data/docs/a.txt", "restored/docs/a.txt"},
+		{"relocate root entry", 0, relocate, "data/", "restored"},
+		{"longest prefix wins", 0, relocate, "data/photos/a.jpg", "pictures/a.jpg"},
+		{"partial component", 0, relocate, "database/a.db", "database/a.db"},
+		{"relocate to destination root", 0, relocate, "home/user/.bashrc", "user/.bashrc"},
+		{"strip then relocate", 1, []Relocation{{Old: "docs", New: "papers"}}, "data/docs/a.txt", "papers/a.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := &extractor{cfg: ExtractConfig{StripComponents: tt.strip, Relocate: tt.rules}}
+			assert.Equal(t, tt.want, x.rewriteName(tt.input))
+		})
+	}
+}
+
+func TestExtract_StripComponents(t *testing.T) {
+	data := createSelectionArchive(t)
+	destDir := t.TempDir()
+
+	result, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{StripComponents: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 9, result.Selected, "the top-level directory entry is stripped away")
+
+	assert.FileExists(t, filepath.Join(destDir, "docs", "report.pdf"))
+	assert.FileExists(t, filepath.Join(destDir, "photos", "raw", "b.jpg"))
+	assert.NoDirExists(t, filepath.Join(destDir, "data"))
+}
+
+func TestExtract_Relocate(t *testing.T) {
+	data := createSelectionArchive(t)
+	destDir := t.TempDir()
+
+	_, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{
+		Paths:    []string{"data/docs"},
+		Relocate: []Relocation{{Old: "data/docs", New: "restored/2026"}},
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(destDir, "restored", "2026", "report.pdf"))
+	require.NoError(t, err)
+	assert.Equal(t, "docs/report.pdf", string(content))
+	assert.NoDirExists(t, filepath.Join(destDir, "data"))
+}
+
+func TestExtract_RewriteTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "data/../../../etc/evil",
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     4,
+	}))
+	_, err := tw.Write([]byte("evil"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	destDir := t.TempDir()
+	_, err = Extract(bytes.NewReader(buf.Bytes()), destDir, ExtractConfig{
+		Relocate: []Relocation{{Old: "data", New: "restored"}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "path traversal")
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(destDir), "etc", "evil"))
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// normalizeHeader removes everything from a header that does not follow from
+// the archived content, so identical trees produce identical archives:
+// access and change times, and user/group names (which depend on the host's
+// user database). Modification times later than CreateConfig.ClampMtime are
+// clamped to it.
+func (a *archiver) normalizeHeader(header *tar.Header) {
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.Uname = ""
+	header.Gname = ""
+	if clamp := a.cfg.ClampMtime; !clamp.IsZero() && header.ModTime.After(clamp) {
+		header.ModTime = clamp
+	}
+}
+
+// sortSources orders source paths by their archive prefix, so the archive
+// does not depend on the order sources were given in
+func sortSources(absPaths []string) {
+	sort.Slice(absPaths, func(i, j int) bool {
+		return filepath.Base(absPaths[i]) < filepath.Base(absPaths[j])
+	})
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree creates the same small tree under root/data, with fixed
+// modification times, and returns the source path
+func writeTree(t *testing.T, root string) string {
+	t.Helper()
+	src := filepath.Join(root, "data")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "a.txt"), []byte("alpha"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("beta"), 0600))
+
+	mtime := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, p := range []string{"a.txt", "sub/b.txt", "sub", "."} {
+		require.NoError(t, os.Chtimes(filepath.Join(src, p), mtime, mtime))
+	}
+	return src
+}
+
+func TestCreateTar_Reproducible(t *testing.T) {
+	first := writeTree(t, t.TempDir())
+	time.Sleep(10 * time.Millisecond) // Different change times
+	second := writeTree(t, t.TempDir())
+
+	// Reading a file changes its access time
+	_, err := os.ReadFile(filepath.Join(second, "a.txt"))
+	require.NoError(t, err)
+
+	archive := func(src string, cfg CreateConfig) []byte {
+		var buf bytes.Buffer
+		_, err := CreateTar(src, &buf, cfg)
+		require.NoError(t, err)
+		return buf.Bytes()
+	}
+
+	cfg := CreateConfig{Reproducible: true}
+	assert.Equal(t, archive(first, cfg), archive(second, cfg), "identical trees give identical archives")
+	assert.NotEqual(t, archive(first, CreateConfig{}), archive(second, CreateConfig{}), "change times differ by default")
+
+	// Headers carry nothing host or time dependent
+	tr := tar.NewReader(bytes.NewReader(archive(first, cfg)))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.True(t, header.AccessTime.IsZero(), header.Name)
+		assert.True(t, header.ChangeTime.IsZero(), header.Name)
+		assert.Empty(t, header.Uname, header.Name)
+		assert.Empty(t, header.Gname, header.Name)
+	}
+}
+
+func TestCreateTar_ReproducibleClampMtime(t *testing.T) {
+	src := writeTree(t, t.TempDir())
+	newer := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(src, "a.txt"), newer, newer))
+
+	clamp := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	_, err := CreateTar(src, &buf, CreateConfig{Reproducible: true, ClampMtime: clamp})
+	require.NoError(t, err)
+
+	mtimes := make(map[string]time.Time)
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		mtimes[header.Name] = header.ModTime.UTC()
+	}
+	assert.Equal(t, clamp, mtimes[filepath.Join("data", "a.txt")], "later times are clamped")
+	assert.Equal(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), mtimes[filepath.Join("data", "sub", "b.txt")], "earlier times are kept")
+}
+
+func TestCreateTar_ReproducibleSourceOrder(t *testing.T) {
+	root := t.TempDir()
+	one := filepath.Join(root, "one")
+	two := filepath.Join(root, "two")
+	require.NoError(t, os.Mkdir(one, 0755))
+	require.NoError(t, os.Mkdir(two, 0755))
+
+	var forward, backward bytes.Buffer
+	_, err := CreateTarSources([]string{one, two}, &forward, CreateConfig{Reproducible: true})
+	require.NoError(t, err)
+	_, err = CreateTarSources([]string{two, one}, &backward, CreateConfig{Reproducible: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"one", "two"}, listTarEntries(t, backward.Bytes()))
+	assert.Equal(t, forward.Bytes(), backward.Bytes())
+}
+
+func TestCreateTar_ReproducibleSparse(t *testing.T) {
+	src := t.TempDir()
+	f, err := os.Create(filepath.Join(src, "sparse.img"))
+	require.NoError(t, err)
+	require.NoError(t, f.Truncate(4<<20))
+	_, err = f.WriteAt([]byte("data"), 2<<20)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	var buf bytes.Buffer
+	_, err = CreateTar(src, &buf, CreateConfig{Reproducible: true})
+	require.NoError(t, err)
+
+	// Stored in full: hole detection depends on the file system
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.NotContains(t, header.Name, "GNUSparseFile", "sparse files are stored in full")
+		if header.Typeflag == tar.TypeReg {
+			assert.Equal(t, int64(4<<20), header.Size)
+			assert.Empty(t, header.PAXRecords[paxSparseMajor])
+		}
+	}
+}
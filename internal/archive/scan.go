@@ -0,0 +1,266 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Entry is a filesystem entry selected for archiving. A scan holds one Entry
+// per file of every source, so it keeps only what is needed before the entry
+// is written; WriteTar stats every entry again. Use Scan.Path for its path.
+type Entry struct {
+	Name  string      // Name inside the archive
+	Size  int64       // Size of regular files at scan time
+	Mode  fs.FileMode // Lstat mode at scan time
+	Links uint32      // Hard link count at scan time (0 where unknown)
+	src   int32       // Index of the entry's source in Scan.bases
+}
+
+// Scan lists everything a backup will archive. It is built by a single walk of
+// the sources and shared by size estimates, progress totals and WriteTar.
+type Scan struct {
+	Entries []Entry
+	Sizes   map[string]int64 // Regular file bytes per source path, as given
+
+	cfg    CreateConfig
+	result Result   // Skipped mount points and unreadable directories
+	bases  []string // Directory containing each source, in walk order
+}
+
+// Path returns the absolute path of an entry on disk
+func (s *Scan) Path(e Entry) string {
+	return filepath.Join(s.bases[e.src], e.Name)
+}
+
+// Size returns the total regular file bytes of all sources
+func (s *Scan) Size() int64 {
+	var size int64
+	for _, n := range s.Sizes {
+		size += n
+	}
+	return size
+}
+
+// ScanSources walks the sources once, applying filters, ignore files, cache
+// directory tags, the special file policy and one-file-system, and returns
+// the entries to archive in archive order.
+func ScanSources(sourcePaths []string, cfg CreateConfig) (*Scan, error) {
+	if _, err := SourcePrefixes(sourcePaths); err != nil {
+		return nil, err
+	}
+
+	// Resolve and check every source before walking anything
+	absPaths := make([]string, len(sourcePaths))
+	sources := make(map[string]string, len(sourcePaths))
+	for i, sourcePath := range sourcePaths {
+		absPath, err := filepath.Abs(sourcePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
+		}
+		// Lstat to avoid following if source itself is a symlink
+		if _, err := os.Lstat(absPath); err != nil {
+			return nil, fmt.Errorf("failed to stat source path: %w", err)
+		}
+		absPaths[i] = absPath
+		sources[absPath] = sourcePath
+	}
+	if cfg.Reproducible {
+		sortSources(absPaths)
+	}
+
+	s := &scanner{
+		archiver: archiver{cfg: cfg},
+		scan:     &Scan{Sizes: make(map[string]int64, len(sourcePaths)), cfg: cfg},
+	}
+	for _, absPath := range absPaths {
+		size, err := s.addSource(absPath)
+		if err != nil {
+			return nil, err
+		}
+		s.scan.Sizes[sources[absPath]] = size
+	}
+	s.scan.result = s.result
+
+	return s.scan, nil
+}
+
+// scanner holds the state of a single ScanSources run. The embedded archiver
+// provides the configuration and the result shared with WriteTar.
+type scanner struct {
+	archiver
+	scan *Scan
+}
+
+// add appends an entry of the current source to the scan and returns its
+// regular file bytes
+func (s *scanner) add(relPath string, info fs.FileInfo) int64 {
+	e := Entry{Name: relPath, Mode: info.Mode(), src: int32(len(s.scan.bases) - 1)}
+	if _, nlink, ok := fileIdentity(info); ok {
+		e.Links = uint32(nlink)
+	}
+	if info.Mode().IsRegular() {
+		e.Size = info.Size()
+	}
+	s.scan.Entries = append(s.scan.Entries, e)
+	return e.Size
+}
+
+// addSource lists one source tree under its base name and returns its regular
+// file bytes
+func (s *scanner) addSource(absPath string) (int64, error) {
+	cfg := s.cfg
+
+	sourceInfo, err := os.Lstat(absPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat source path: %w", err)
+	}
+
+	// Base directory for relative paths
+	baseDir := filepath.Dir(absPath)
+	baseName := filepath.Base(absPath)
+	s.scan.bases = append(s.scan.bases, baseDir)
+
+	// Per-directory ignore rules, loaded as directories are visited
+	var ignores *ignoreSet
+	if cfg.IgnoreFile != "" && sourceInfo.IsDir() {
+		ignores = newIgnoreSet(cfg.IgnoreFile)
+	}
+
+	// Device of the source root, compared against each directory in one-file-system mode
+	var rootDev uint64
+	checkDev := false
+	if cfg.OneFileSystem {
+		if id, _, ok := fileIdentity(sourceInfo); ok {
+			rootDev, checkDev = id.dev, true
+		}
+	}
+
+	// Walk the directory tree (WalkDir uses Lstat — does not follow symlinks)
+	var size int64
+	err = filepath.WalkDir(absPath, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Entries removed during the scan are simply not part of the backup
+			if errors.Is(err, fs.ErrNotExist) && file != absPath {
+				return nil
+			}
+			// An unreadable directory is kept without its contents
+			return s.unreadable(file, fmt.Errorf("walk error at %s: %w", file, err))
+		}
+
+		// Compute relative path for archive
+		var relPath string
+		if sourceInfo.IsDir() {
+			relPath, err = filepath.Rel(baseDir, file)
+			if err != nil {
+				return fmt.Errorf("failed to get relative path: %w", err)
+			}
+		} else {
+			if file == absPath {
+				relPath = baseName
+			} else {
+				relPath = filepath.Base(file)
+			}
+		}
+
+		// Apply include/exclude patterns (the source root itself is always archived)
+		if file != absPath && cfg.Filter.Excluded(relPath, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		// Apply ignore files from this entry's ancestors
+		var walkRel string
+		if ignores != nil {
+			walkRel, err = filepath.Rel(absPath, file)
+			if err != nil {
+				return fmt.Errorf("failed to get relative path: %w", err)
+			}
+			walkRel = filepath.ToSlash(walkRel)
+			if walkRel == "." {
+				walkRel = ""
+			}
+			if walkRel != "" && ignores.ignored(walkRel, d.IsDir()) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+		}
+
+		// Get file info (WalkDir entries are Lstat-based — symlinks are not followed)
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return s.unreadable(file, fmt.Errorf("failed to get file info for %s: %w", file, err))
+		}
+
+		// Devices, FIFOs and sockets follow the special file policy
+		if kind := specialKind(info.Mode()); kind != "" {
+			include, err := s.includeSpecial(file, kind)
+			if err != nil || !include {
+				return err
+			}
+		}
+
+		// Load this directory's own ignore file
+		if ignores != nil && d.IsDir() {
+			if err := ignores.load(file, walkRel); err != nil {
+				// Without its ignore rules the directory is kept, but not its contents
+				if err := s.unreadable(file, err); err != nil {
+					return err
+				}
+				s.add(relPath, info)
+				return fs.SkipDir
+			}
+		}
+
+		size += s.add(relPath, info)
+
+		// Mount points are archived as empty directories
+		if checkDev && d.IsDir() && file != absPath {
+			if id, _, ok := fileIdentity(info); ok && id.dev != rootDev {
+				fmt.Fprintf(os.Stderr, "Warning: skipping mount point %s (different file system)\n", file)
+				s.result.SkippedMounts = append(s.result.SkippedMounts, file)
+				return fs.SkipDir
+			}
+		}
+
+		// Keep only the CACHEDIR.TAG of tagged cache directories
+		if cfg.ExcludeCaches && d.IsDir() && isCacheDir(file) {
+			tagPath := filepath.Join(file, CacheDirTagName)
+			tagInfo, err := os.Lstat(tagPath)
+			if err != nil {
+				return fmt.Errorf("failed to stat %s: %w", tagPath, err)
+			}
+			size += s.add(filepath.Join(relPath, CacheDirTagName), tagInfo)
+			return fs.SkipDir
+		}
+
+		return nil
+	})
+
+	return size, err
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanSources(t *testing.T) {
+	root := t.TempDir()
+	docs := filepath.Join(root, "docs")
+	photos := filepath.Join(root, "photos")
+	require.NoError(t, os.MkdirAll(filepath.Join(docs, "sub"), 0755))
+	require.NoError(t, os.MkdirAll(photos, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "a.txt"), make([]byte, 100), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "sub", "b.txt"), make([]byte, 50), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "scratch.tmp"), make([]byte, 1000), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(photos, "c.jpg"), make([]byte, 7), 0644))
+
+	scan, err := ScanSources([]string{docs, photos}, CreateConfig{Filter: Filter{Excludes: []string{"*.tmp"}}})
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range scan.Entries {
+		names = append(names, e.Name)
+	}
+	assert.Equal(t, []string{
+		"docs",
+		filepath.Join("docs", "a.txt"),
+		filepath.Join("docs", "sub"),
+		filepath.Join("docs", "sub", "b.txt"),
+		"photos",
+		filepath.Join("photos", "c.jpg"),
+	}, names)
+	assert.Equal(t, map[string]int64{docs: 150, photos: 7}, scan.Sizes)
+	assert.Equal(t, int64(157), scan.Size())
+	for _, e := range scan.Entries {
+		assert.Equal(t, filepath.Join(root, e.Name), scan.Path(e))
+	}
+
+	var buf bytes.Buffer
+	_, err = WriteTar(&buf, scan)
+	require.NoError(t, err)
+	assert.Equal(t, names, listTarEntries(t, buf.Bytes()), "the archive follows the scan")
+
+	// Writing a scan gives the same archive as CreateTarSources (access times
+	// change as files are read, so compare reproducible archives)
+	cfg := CreateConfig{Filter: Filter{Excludes: []string{"*.tmp"}}, Reproducible: true}
+	scan, err = ScanSources([]string{docs, photos}, cfg)
+	require.NoError(t, err)
+	var fromScan, direct bytes.Buffer
+	_, err = WriteTar(&fromScan, scan)
+	require.NoError(t, err)
+	_, err = CreateTarSources([]string{docs, photos}, &direct, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, direct.Bytes(), fromScan.Bytes())
+}
+
+func TestWriteTar_ChangedSinceScan(t *testing.T) {
+	src := t.TempDir()
+	base := filepath.Base(src)
+	grown := filepath.Join(src, "grown.log")
+	gone := filepath.Join(src, "gone.txt")
+	require.NoError(t, os.WriteFile(grown, []byte("line 1\n"), 0644))
+	require.NoError(t, os.WriteFile(gone, []byte("temporary"), 0644))
+
+	scan, err := ScanSources([]string{src}, CreateConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("line 1\n")+len("temporary")), scan.Size())
+
+	// Between the scan and archiving, one file grows and one is removed
+	require.NoError(t, os.WriteFile(grown, []byte("line 1\nline 2\n"), 0644))
+	require.NoError(t, os.Remove(gone))
+
+	var buf bytes.Buffer
+	result, err := WriteTar(&buf, scan)
+	require.NoError(t, err)
+	assert.Equal(t, []string{gone}, result.VanishedFiles)
+	assert.Empty(t, result.ChangedFiles, "changes before the read are not inconsistencies")
+	assert.Empty(t, result.Errors)
+
+	// The grown file is archived as it is now
+	tr := tar.NewReader(&buf)
+	contents := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		contents[header.Name] = string(data)
+	}
+	assert.Equal(t, map[string]string{
+		base:                             "",
+		filepath.Join(base, "grown.log"): "line 1\nline 2\n",
+	}, contents)
+}
+
+func TestScanSources_FileSource(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "notes.txt")
+	require.NoError(t, os.WriteFile(file, make([]byte, 10), 0644))
+
+	scan, err := ScanSources([]string{file}, CreateConfig{})
+	require.NoError(t, err)
+	require.Len(t, scan.Entries, 1)
+	e := scan.Entries[0]
+	assert.Equal(t, "notes.txt", e.Name)
+	assert.Equal(t, file, scan.Path(e))
+	assert.Equal(t, int64(10), e.Size)
+	assert.True(t, e.Mode.IsRegular())
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// CleanArchivePath normalizes a user-supplied archive path ("/docs/a/" → "docs/a").
+// Returns "" for paths that refer to the archive root.
+func CleanArchivePath(p string) string {
+	p = path.Clean("/" + filepath.ToSlash(p))
+	return strings.TrimPrefix(p, "/")
+}
+
+// selective reports whether only part of the archive is extracted
+func (x *extractor) selective() bool {
+	return len(x.cfg.Paths) > 0 || !x.cfg.Filter.IsEmpty()
+}
+
+// selected reports whether an entry matches the restore selection.
+//
+// An entry is selected when it is one of Paths or below one of them, and it is
+// not excluded. As during archiving, excluding a directory excludes everything
+// below it. Include patterns apply to directories too: when includes are set,
+// parents of selected files are created as needed instead of restored.
+func (x *extractor) selected(header *tar.Header) bool {
+	if !x.selective() {
+		return true
+	}
+
+	name := CleanArchivePath(header.Name)
+	isDir := header.Typeflag == tar.TypeDir
+
+	if len(x.cfg.Paths) > 0 {
+		found := false
+		for _, p := range x.cfg.Paths {
+			p = CleanArchivePath(p)
+			if p == "" || name == p || strings.HasPrefix(name, p+"/") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Excluded ancestor directories exclude the entry
+	excludes := Filter{Excludes: x.cfg.Filter.Excludes}
+	for i := strings.IndexByte(name, '/'); i >= 0; i = nextSlash(name, i) {
+		if excludes.Excluded(name[:i], true) {
+			return false
+		}
+	}
+
+	// Directories are only kept by include patterns they match themselves
+	if isDir && len(x.cfg.Filter.Includes) > 0 {
+		return !x.cfg.Filter.Excluded(name, false)
+	}
+	return !x.cfg.Filter.Excluded(name, isDir)
+}
+
+// linkTargetSelected reports whether the target of a hard link entry is part of
+// the restore selection. Link entries carry no data, so a link whose target is
+// not restored cannot be recreated. Must be called before rewriteHeader, while
+// the link target is still an archive name.
+func (x *extractor) linkTargetSelected(header *tar.Header) bool {
+	if header.Typeflag != tar.TypeLink {
+		return true
+	}
+	return x.selected(&tar.Header{Name: header.Linkname, Typeflag: tar.TypeReg})
+}
+
+// nextSlash returns the index of the next '/' in name after position i, or -1
+func nextSlash(name string, i int) int {
+	j := strings.IndexByte(name[i+1:], '/')
+	if j < 0 {
+		return -1
+	}
+	return i + 1 + j
+}
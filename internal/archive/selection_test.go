@@ -0,0 +1,182 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanArchivePath(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"docs/report.pdf", "docs/report.pdf"},
+		{"/docs/", "docs"},
+		{"./docs//a/../b", "docs/b"},
+		{"../../etc", "etc"},
+		{"/", ""},
+		{".", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, CleanArchivePath(tt.input))
+		})
+	}
+}
+
+// createSelectionArchive archives a small tree rooted at "data" and returns the tar bytes
+func createSelectionArchive(t *testing.T) []byte {
+	t.Helper()
+	srcDir := filepath.Join(t.TempDir(), "data")
+	for _, name := range []string{"docs/report.pdf", "docs/notes.txt", "photos/a.jpg", "photos/raw/b.jpg", "cache/x.tmp"} {
+		path := filepath.Join(srcDir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(name), 0644))
+	}
+
+	var buf bytes.Buffer
+	_, err := CreateTar(srcDir, &buf, CreateConfig{})
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestExtract_Selection(t *testing.T) {
+	data := createSelectionArchive(t)
+
+	tests := []struct {
+		name     string
+		cfg      ExtractConfig
+		want     []string
+		selected int
+	}{
+		{
+			name:     "everything",
+			cfg:      ExtractConfig{},
+			want:     []string{"data/docs/report.pdf", "data/docs/notes.txt", "data/photos/a.jpg", "data/photos/raw/b.jpg", "data/cache/x.tmp"},
+			selected: 10,
+		},
+		{
+			name:     "single file",
+			cfg:      ExtractConfig{Paths: []string{"data/docs/report.pdf"}},
+			want:     []string{"data/docs/report.pdf"},
+			selected: 1,
+		},
+		{
+			name:     "directory subtree",
+			cfg:      ExtractConfig{Paths: []string{"/data/photos/"}},
+			want:     []string{"data/photos/a.jpg", "data/photos/raw/b.jpg"},
+			selected: 4,
+		},
+		{
+			name:     "include pattern",
+			cfg:      ExtractConfig{Filter: Filter{Includes: []string{"*.jpg"}}},
+			want:     []string{"data/photos/a.jpg", "data/photos/raw/b.jpg"},
+			selected: 2,
+		},
+		{
+			name:     "exclude directory",
+			cfg:      ExtractConfig{Paths: []string{"data/photos"}, Filter: Filter{Excludes: []string{"raw"}}},
+			want:     []string{"data/photos/a.jpg"},
+			selected: 2,
+		},
+	}
+
+	all := []string{"data/docs/report.pdf", "data/docs/notes.txt", "data/photos/a.jpg", "data/photos/raw/b.jpg", "data/cache/x.tmp"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destDir := t.TempDir()
+			result, err := Extract(bytes.NewReader(data), destDir, tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, 10, result.Entries)
+			assert.Equal(t, tt.selected, result.Selected)
+
+			for _, name := range all {
+				_, err := os.Stat(filepath.Join(destDir, filepath.FromSlash(name)))
+				if slices.Contains(tt.want, name) {
+					assert.NoError(t, err, name)
+				} else {
+					assert.True(t, os.IsNotExist(err), "%s should not be restored", name)
+				}
+			}
+		})
+	}
+
+	t.Run("nothing written for unmatched path", func(t *testing.T) {
+		destDir := t.TempDir()
+		result, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{Paths: []string{"data/missing"}})
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Selected)
+
+		entries, err := os.ReadDir(destDir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestExtract_SelectionHardLink(t *testing.T) {
+	data := buildTar(t,
+		&tar.Header{Name: "d/a", Typeflag: tar.TypeReg, Mode: 0644},
+		&tar.Header{Name: "d/b", Typeflag: tar.TypeLink, Linkname: "d/a"},
+	)
+
+	// setup creates live copies of both files at the destination
+	setup := func(t *testing.T) string {
+		destDir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(destDir, "d"), 0755))
+		for _, name := range []string{"a", "b"} {
+			require.NoError(t, os.WriteFile(filepath.Join(destDir, "d", name), []byte("live "+name), 0644))
+		}
+		return destDir
+	}
+
+	t.Run("target not selected", func(t *testing.T) {
+		destDir := setup(t)
+		result, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{Paths: []string{"d/b"}})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Selected)
+		assert.Zero(t, result.Overwritten)
+
+		// The link is not pointed at the live target, and the existing file stays
+		for _, name := range []string{"a", "b"} {
+			content, err := os.ReadFile(filepath.Join(destDir, "d", name))
+			require.NoError(t, err)
+			assert.Equal(t, "live "+name, string(content))
+		}
+	})
+
+	t.Run("target selected", func(t *testing.T) {
+		destDir := setup(t)
+		result, err := Extract(bytes.NewReader(data), destDir, ExtractConfig{Paths: []string{"d"}})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Overwritten)
+
+		content, err := os.ReadFile(filepath.Join(destDir, "d", "b"))
+		require.NoError(t, err)
+		assert.Equal(t, "d/a", string(content))
+	})
+}
@@ -0,0 +1,291 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/icemarkom/secure-backup/internal/common"
+)
+
+// PAX records of the GNU sparse format 1.0 (read natively by archive/tar, GNU tar and bsdtar)
+const (
+	paxSparseMajor    = "GNU.sparse.major"
+	paxSparseMinor    = "GNU.sparse.minor"
+	paxSparseName     = "GNU.sparse.name"
+	paxSparseRealSize = "GNU.sparse.realsize"
+	paxSparseMap      = "GNU.sparse.map" // Format 0.1, read only
+	paxPath           = "path"
+)
+
+const blockSize = 512
+
+// sparseRegion is a data region of a sparse file; everything outside the regions is a hole
+type sparseRegion struct {
+	offset int64
+	length int64
+}
+
+// isSparse reports whether a file with the given data regions has any holes
+func isSparse(regions []sparseRegion, size int64) bool {
+	if regions == nil {
+		return false
+	}
+	return len(regions) != 1 || regions[0].offset != 0 || regions[0].length != size
+}
+
+// writeSparse writes a regular file as a PAX sparse entry (GNU format 1.0):
+// the entry data is a block-padded sparse map followed by the data regions only.
+// archive/tar cannot encode sparse entries, so the extended header is
+// assembled here and the entry is written directly to the underlying writer.
+func (a *archiver) writeSparse(header *tar.Header, f *os.File, regions []sparseRegion) error {
+	realName, realSize := header.Name, header.Size
+
+	// GNU tar ends the map with an empty region when the file ends in a hole
+	if n := len(regions); n == 0 || regions[n-1].offset+regions[n-1].length < realSize {
+		regions = append(regions, sparseRegion{offset: realSize})
+	}
+
+	// Encode the sparse map: region count, then offset/length pairs, one number per line
+	var sparseMap []byte
+	sparseMap = append(strconv.AppendInt(sparseMap, int64(len(regions)), 10), '\n')
+	var dataSize int64
+	for _, r := range regions {
+		sparseMap = append(strconv.AppendInt(sparseMap, r.offset, 10), '\n')
+		sparseMap = append(strconv.AppendInt(sparseMap, r.length, 10), '\n')
+		dataSize += r.length
+	}
+	sparseMap = append(sparseMap, make([]byte, blockPadding(int64(len(sparseMap))))...)
+
+	dir, name := path.Split(realName)
+	header.Name = path.Join(dir, "GNUSparseFile.0", name)
+	header.Size = int64(len(sparseMap)) + dataSize
+
+	// Let archive/tar encode the header, then merge the sparse records into
+	// its extended header
+	records, mainBlock, err := encodeHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", realName, err)
+	}
+	delete(records, paxPath) // Recorded by GNU.sparse.name
+	records[paxSparseMajor] = "1"
+	records[paxSparseMinor] = "0"
+	records[paxSparseName] = realName
+	records[paxSparseRealSize] = strconv.FormatInt(realSize, 10)
+
+	// Pad the previous entry before writing raw blocks
+	if err := a.tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", realName, err)
+	}
+	if err := writePAXHeader(a.w, mainBlock, path.Join(dir, "PaxHeaders.0", name), records); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", realName, err)
+	}
+	if _, err := a.w.Write(mainBlock); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", realName, err)
+	}
+	if _, err := a.w.Write(sparseMap); err != nil {
+		return fmt.Errorf("failed to write sparse map for %s: %w", realName, err)
+	}
+
+	// Write the data regions. After a read failure the remaining regions are
+	// zero-filled so the entry stays the size its header announced.
+	buf := a.copyBuffer()
+	var readErr error
+	for _, r := range regions {
+		var src io.Reader = f
+		if readErr == nil {
+			if _, err := f.Seek(r.offset, io.SeekStart); err != nil {
+				readErr = &readError{err: err}
+			}
+		}
+		if readErr != nil {
+			src = zeroReader{}
+		}
+		n, err := copyFileData(a.w, src, r.length, buf)
+		if isReadError(err) {
+			readErr = err
+		} else if err != nil {
+			return fmt.Errorf("failed to write file data for %s: %w", realName, err)
+		}
+		a.result.BytesWritten += n
+	}
+	if _, err := a.w.Write(make([]byte, blockPadding(dataSize))); err != nil {
+		return fmt.Errorf("failed to write file data for %s: %w", realName, err)
+	}
+	if readErr != nil {
+		return fmt.Errorf("failed to read file %s: %w", realName, readErr)
+	}
+
+	return nil
+}
+
+// encodeHeader encodes header with archive/tar and returns the records of its
+// PAX extended header (if any) and the raw main header block
+func encodeHeader(header *tar.Header) (map[string]string, []byte, error) {
+	var buf bytes.Buffer
+	if err := tar.NewWriter(&buf).WriteHeader(header); err != nil {
+		return nil, nil, err
+	}
+	raw := buf.Bytes()
+
+	records := make(map[string]string)
+	if raw[156] == tar.TypeXHeader {
+		size, err := strconv.ParseInt(strings.TrimRight(string(raw[124:136]), " \x00"), 8, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid extended header size: %w", err)
+		}
+		if err := parsePAXRecords(raw[blockSize:blockSize+size], records); err != nil {
+			return nil, nil, err
+		}
+		raw = raw[blockSize+size+blockPadding(size):]
+	}
+
+	return records, raw[:blockSize], nil
+}
+
+// parsePAXRecords parses "<length> <key>=<value>\n" records into records
+func parsePAXRecords(data []byte, records map[string]string) error {
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		if sp < 0 {
+			return fmt.Errorf("invalid PAX record")
+		}
+		n, err := strconv.Atoi(string(data[:sp]))
+		if err != nil || n <= sp || n > len(data) || data[n-1] != '\n' {
+			return fmt.Errorf("invalid PAX record")
+		}
+		key, value, ok := strings.Cut(string(data[sp+1:n-1]), "=")
+		if !ok {
+			return fmt.Errorf("invalid PAX record")
+		}
+		records[key] = value
+		data = data[n:]
+	}
+	return nil
+}
+
+// writePAXHeader writes a PAX extended header ('x') entry holding records,
+// using mainBlock (the header it describes) as the template
+func writePAXHeader(w io.Writer, mainBlock []byte, name string, records map[string]string) error {
+	keys := make([]string, 0, len(records))
+	for k := range records {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var data []byte
+	for _, k := range keys {
+		data = append(data, formatPAXRecord(k, records[k])...)
+	}
+
+	block := make([]byte, blockSize)
+	copy(block, mainBlock)
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	copy(block[0:100], append([]byte(name), make([]byte, 100-len(name))...)) // name
+	copy(block[124:136], fmt.Sprintf("%011o\x00", len(data)))                // size
+	block[156] = tar.TypeXHeader                                             // typeflag
+	copy(block[157:257], make([]byte, 100))                                  // linkname
+	setChecksum(block)
+
+	if _, err := w.Write(block); err != nil {
+		return err
+	}
+	data = append(data, make([]byte, blockPadding(int64(len(data))))...)
+	_, err := w.Write(data)
+	return err
+}
+
+// formatPAXRecord formats a single PAX record; the length prefix counts itself
+func formatPAXRecord(key, value string) string {
+	size := len(key) + len(value) + 3 // Space, '=' and newline
+	size += len(strconv.Itoa(size))
+	record := strconv.Itoa(size) + " " + key + "=" + value + "\n"
+	if len(record) != size {
+		// Adding the length prefix carried into another digit
+		size = len(record)
+		record = strconv.Itoa(size) + " " + key + "=" + value + "\n"
+	}
+	return record
+}
+
+// setChecksum computes the header checksum (field treated as spaces while summing)
+func setChecksum(block []byte) {
+	copy(block[148:156], "        ")
+	var sum int64
+	for _, b := range block {
+		sum += int64(b)
+	}
+	copy(block[148:156], fmt.Sprintf("%06o\x00 ", sum))
+}
+
+// blockPadding returns the number of bytes needed to pad n to a full tar block
+func blockPadding(n int64) int64 {
+	return -n & (blockSize - 1)
+}
+
+// isSparseHeader reports whether an extracted entry was archived as a sparse file
+func isSparseHeader(header *tar.Header) bool {
+	return header.Typeflag == tar.TypeGNUSparse ||
+		header.PAXRecords[paxSparseMajor] != "" ||
+		header.PAXRecords[paxSparseMap] != ""
+}
+
+// writeSparseFile copies a sparse entry's data to f, seeking over zero-filled
+// blocks instead of writing them so that holes are recreated, then sets the
+// final size (which also covers a trailing hole)
+func writeSparseFile(f *os.File, r io.Reader, size int64) (int64, error) {
+	buf := common.NewBuffer()
+	zero := make([]byte, blockSize*8)
+	var off int64
+
+	for {
+		n, err := io.ReadFull(r, buf)
+		for chunk := buf[:n]; len(chunk) > 0; {
+			c := chunk[:min(len(zero), len(chunk))]
+			if bytes.Equal(c, zero[:len(c)]) {
+				if _, serr := f.Seek(int64(len(c)), io.SeekCurrent); serr != nil {
+					return off, serr
+				}
+			} else if _, werr := f.Write(c); werr != nil {
+				return off, werr
+			}
+			off += int64(len(c))
+			chunk = chunk[len(c):]
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return off, err
+		}
+	}
+
+	if err := f.Truncate(size); err != nil {
+		return off, err
+	}
+	return off, nil
+}
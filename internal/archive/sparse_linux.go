@@ -0,0 +1,67 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// dataRegions returns the data regions of f using SEEK_DATA/SEEK_HOLE.
+// Returns nil when the file has all its blocks allocated (it cannot have holes)
+// or the filesystem cannot report holes.
+func dataRegions(f *os.File, fi fs.FileInfo) ([]sparseRegion, error) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	size := fi.Size()
+	if !ok || size == 0 || st.Blocks*512 >= size {
+		return nil, nil
+	}
+
+	var regions []sparseRegion
+	for off := int64(0); off < size; {
+		start, err := f.Seek(off, unix.SEEK_DATA)
+		if errors.Is(err, syscall.ENXIO) {
+			break // Only a hole remains
+		}
+		if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.EOPNOTSUPP) {
+			return nil, nil // Holes cannot be reported; archive as a regular file
+		}
+		if err != nil {
+			return nil, err
+		}
+		end, err := f.Seek(start, unix.SEEK_HOLE)
+		if err != nil {
+			return nil, err
+		}
+		end = min(end, size)
+		regions = append(regions, sparseRegion{offset: start, length: end - start})
+		off = end
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if regions == nil {
+		regions = []sparseRegion{} // Entirely a hole
+	}
+	return regions, nil
+}
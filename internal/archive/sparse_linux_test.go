@@ -0,0 +1,140 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createSparseFile creates a 32 MiB file with two small data regions.
+// Skips the test if the filesystem does not create holes.
+func createSparseFile(t *testing.T, path string) []byte {
+	t.Helper()
+
+	const size = 32 << 20
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, f.Truncate(size))
+	_, err = f.WriteAt([]byte("hello"), 8<<20)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte("world"), 20<<20)
+	require.NoError(t, err)
+
+	fi, err := f.Stat()
+	require.NoError(t, err)
+	if fi.Sys().(*syscall.Stat_t).Blocks*512 >= size {
+		t.Skip("filesystem does not support sparse files")
+	}
+
+	content := make([]byte, size)
+	copy(content[8<<20:], "hello")
+	copy(content[20<<20:], "world")
+	return content
+}
+
+func TestDataRegions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sparse.img")
+	createSparseFile(t, path)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	fi, err := f.Stat()
+	require.NoError(t, err)
+
+	regions, err := dataRegions(f, fi)
+	require.NoError(t, err)
+	require.Len(t, regions, 2)
+	assert.LessOrEqual(t, regions[0].offset, int64(8<<20))
+	assert.LessOrEqual(t, regions[1].offset, int64(20<<20))
+	assert.True(t, isSparse(regions, fi.Size()))
+
+	// Fully allocated files are not inspected
+	plain := filepath.Join(t.TempDir(), "plain")
+	require.NoError(t, os.WriteFile(plain, []byte("data"), 0644))
+	pf, err := os.Open(plain)
+	require.NoError(t, err)
+	defer pf.Close()
+	pfi, err := pf.Stat()
+	require.NoError(t, err)
+	regions, err = dataRegions(pf, pfi)
+	require.NoError(t, err)
+	assert.Nil(t, regions)
+}
+
+func TestCreateTar_SparseFile(t *testing.T) {
+	srcDir := t.TempDir()
+	base := filepath.Base(srcDir)
+	want := createSparseFile(t, filepath.Join(srcDir, "disk.img"))
+	setTestXattr(t, filepath.Join(srcDir, "disk.img"), "user.comment", "vm image")
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "plain.txt"), []byte("plain"), 0644))
+
+	var buf bytes.Buffer
+	_, err := CreateTar(srcDir, &buf, CreateConfig{})
+	require.NoError(t, err)
+
+	// Holes are not stored
+	assert.Less(t, buf.Len(), 1<<20)
+
+	// archive/tar reads the sparse entry back with holes expanded
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	found := false
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if header.Name == filepath.Join(base, "disk.img") {
+			found = true
+			assert.Equal(t, int64(len(want)), header.Size)
+			// Records from archive/tar's own extended header are kept
+			assert.Equal(t, "vm image", header.PAXRecords[paxXattrPrefix+"user.comment"])
+			got, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(want, got), "sparse content mismatch")
+		}
+	}
+	assert.True(t, found, "sparse entry not found")
+
+	// Extraction recreates the holes
+	destDir := t.TempDir()
+	require.NoError(t, ExtractTar(bytes.NewReader(buf.Bytes()), destDir, ExtractConfig{}))
+
+	extracted := filepath.Join(destDir, base, "disk.img")
+	got, err := os.ReadFile(extracted)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(want, got), "extracted content mismatch")
+
+	fi, err := os.Stat(extracted)
+	require.NoError(t, err)
+	assert.Less(t, fi.Sys().(*syscall.Stat_t).Blocks*512, fi.Size(), "extracted file is fully allocated")
+
+	plain, err := os.ReadFile(filepath.Join(destDir, base, "plain.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "plain", string(plain))
+}
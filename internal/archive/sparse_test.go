@@ -0,0 +1,70 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatPAXRecord(t *testing.T) {
+	assert.Equal(t, "12 path=abc\n", formatPAXRecord("path", "abc"))
+	// Length prefix carries into another digit
+	assert.Equal(t, "101 k="+strings.Repeat("v", 94)+"\n", formatPAXRecord("k", strings.Repeat("v", 94)))
+
+	records := make(map[string]string)
+	data := formatPAXRecord("path", "a=b") + formatPAXRecord("GNU.sparse.major", "1")
+	require.NoError(t, parsePAXRecords([]byte(data), records))
+	assert.Equal(t, map[string]string{"path": "a=b", "GNU.sparse.major": "1"}, records)
+
+	assert.Error(t, parsePAXRecords([]byte("99 path=abc\n"), records))
+}
+
+func TestIsSparse(t *testing.T) {
+	assert.False(t, isSparse(nil, 100))
+	assert.False(t, isSparse([]sparseRegion{{0, 100}}, 100))
+	assert.True(t, isSparse([]sparseRegion{}, 100))
+	assert.True(t, isSparse([]sparseRegion{{4096, 100}}, 8192))
+}
+
+func TestWriteSparseFile(t *testing.T) {
+	data := make([]byte, 64*1024)
+	copy(data[8192:], "hello")
+	copy(data[40000:], "world")
+
+	path := filepath.Join(t.TempDir(), "sparse")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+
+	// Trailing hole is covered by the final size
+	size := int64(len(data)) + 1<<20
+	_, err = writeSparseFile(f, bytes.NewReader(data), size)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, got, int(size))
+	assert.Equal(t, data, got[:len(data)])
+	assert.Equal(t, make([]byte, 1<<20), got[len(data):])
+}
@@ -0,0 +1,147 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SpecialFilePolicy controls how device nodes, FIFOs and sockets are archived.
+type SpecialFilePolicy int
+
+const (
+	// SpecialStore archives devices and FIFOs; sockets cannot be archived and are skipped.
+	SpecialStore SpecialFilePolicy = iota
+	// SpecialSkip leaves all special files out of the archive.
+	SpecialSkip
+	// SpecialFail aborts archiving when a special file is found.
+	SpecialFail
+)
+
+// String names for special file policies, used in CLI flags.
+const (
+	SpecialFilesStore = "store"
+	SpecialFilesSkip  = "skip"
+	SpecialFilesFail  = "fail"
+)
+
+// String returns the CLI name of the policy.
+func (p SpecialFilePolicy) String() string {
+	switch p {
+	case SpecialStore:
+		return SpecialFilesStore
+	case SpecialSkip:
+		return SpecialFilesSkip
+	case SpecialFail:
+		return SpecialFilesFail
+	default:
+		return fmt.Sprintf("unknown(%d)", int(p))
+	}
+}
+
+// SpecialFilePolicyNames returns a comma-separated string of valid policy names.
+func SpecialFilePolicyNames() string {
+	return strings.Join([]string{SpecialFilesSkip, SpecialFilesStore, SpecialFilesFail}, ", ")
+}
+
+// ParseSpecialFilePolicy converts a policy name to a SpecialFilePolicy.
+func ParseSpecialFilePolicy(s string) (SpecialFilePolicy, error) {
+	switch strings.ToLower(s) {
+	case SpecialFilesStore:
+		return SpecialStore, nil
+	case SpecialFilesSkip:
+		return SpecialSkip, nil
+	case SpecialFilesFail:
+		return SpecialFail, nil
+	default:
+		return 0, fmt.Errorf("unknown special file policy: %s", s)
+	}
+}
+
+// errSpecialUnsupported is returned when special files cannot be created on this platform
+var errSpecialUnsupported = errors.New("special files not supported on this platform")
+
+// specialKind describes a special file mode, or returns "" for other file types
+func specialKind(mode fs.FileMode) string {
+	switch {
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeNamedPipe != 0:
+		return "FIFO"
+	case mode&os.ModeCharDevice != 0:
+		return "character device"
+	case mode&os.ModeDevice != 0:
+		return "block device"
+	default:
+		return ""
+	}
+}
+
+// includeSpecial applies the special file policy to a device, FIFO or socket.
+// Returns false if the entry must be left out of the archive.
+func (a *archiver) includeSpecial(file, kind string) (bool, error) {
+	switch {
+	case a.cfg.SpecialFiles == SpecialFail:
+		return false, fmt.Errorf("found %s %s (special files are not allowed)", kind, file)
+	case kind == "socket":
+		// Sockets only exist while their server runs; tar cannot store them
+		fmt.Fprintf(os.Stderr, "Warning: skipping socket %s\n", file)
+		return false, nil
+	default:
+		return a.cfg.SpecialFiles == SpecialStore, nil
+	}
+}
+
+// specialRestorable reports whether a device node or FIFO can be recreated,
+// warning about entries that are skipped
+func (x *extractor) specialRestorable(header *tar.Header) bool {
+	if header.Typeflag != tar.TypeFifo && !x.cfg.Devices {
+		fmt.Fprintf(os.Stderr, "Warning: skipping device %s (recreating devices requires root)\n", header.Name)
+		return false
+	}
+	if !mknodSupported {
+		fmt.Fprintf(os.Stderr, "Warning: skipping special file %s: %v\n", header.Name, errSpecialUnsupported)
+		return false
+	}
+	return true
+}
+
+// createSpecial recreates a device node or FIFO checked by specialRestorable
+func (x *extractor) createSpecial(header *tar.Header, targetPath string) error {
+	// Create parent directory if needed
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory for %s: %w", targetPath, err)
+	}
+
+	// Replace an existing file (mknod does not overwrite)
+	if info, err := os.Lstat(targetPath); err == nil && !info.IsDir() {
+		if err := os.Remove(targetPath); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", targetPath, err)
+		}
+	}
+
+	if err := mknod(targetPath, header); err != nil {
+		return fmt.Errorf("failed to create special file %s: %w", targetPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux || darwin
+
+package archive
+
+import (
+	"archive/tar"
+
+	"golang.org/x/sys/unix"
+)
+
+// mknodSupported reports whether mknod can create special files on this platform
+const mknodSupported = true
+
+// mknod creates the device node or FIFO described by header
+func mknod(path string, header *tar.Header) error {
+	mode := uint32(header.Mode & 07777)
+	switch header.Typeflag {
+	case tar.TypeFifo:
+		return unix.Mkfifo(path, mode)
+	case tar.TypeChar:
+		mode |= unix.S_IFCHR
+	case tar.TypeBlock:
+		mode |= unix.S_IFBLK
+	}
+	dev := unix.Mkdev(uint32(header.Devmajor), uint32(header.Devminor))
+	return unix.Mknod(path, mode, int(dev))
+}
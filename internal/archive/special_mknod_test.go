@@ -0,0 +1,127 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux || darwin
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// createSpecialTree creates a source tree with a FIFO, a listening socket and a regular file
+func createSpecialTree(t *testing.T) string {
+	t.Helper()
+	srcDir := t.TempDir()
+
+	require.NoError(t, unix.Mkfifo(filepath.Join(srcDir, "pipe"), 0640))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "file.txt"), []byte("data"), 0644))
+
+	l, err := net.Listen("unix", filepath.Join(srcDir, "app.sock"))
+	if err != nil {
+		t.Skipf("cannot create unix socket: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	return srcDir
+}
+
+func TestCreateTar_SpecialFiles(t *testing.T) {
+	srcDir := createSpecialTree(t)
+	base := filepath.Base(srcDir)
+
+	// store: FIFO archived, socket skipped
+	var buf bytes.Buffer
+	_, err := CreateTar(srcDir, &buf, CreateConfig{SpecialFiles: SpecialStore})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		base,
+		filepath.Join(base, "file.txt"),
+		filepath.Join(base, "pipe"),
+	}, listTarEntries(t, buf.Bytes()))
+
+	// skip: neither archived
+	buf.Reset()
+	_, err = CreateTar(srcDir, &buf, CreateConfig{SpecialFiles: SpecialSkip})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		base,
+		filepath.Join(base, "file.txt"),
+	}, listTarEntries(t, buf.Bytes()))
+
+	// fail: archiving aborts, during the scan before anything is written
+	buf.Reset()
+	_, err = CreateTar(srcDir, &buf, CreateConfig{SpecialFiles: SpecialFail})
+	assert.Error(t, err)
+	assert.Zero(t, buf.Len())
+}
+
+func TestExtractTar_FIFO(t *testing.T) {
+	srcDir := createSpecialTree(t)
+	base := filepath.Base(srcDir)
+
+	var buf bytes.Buffer
+	_, err := CreateTar(srcDir, &buf, CreateConfig{})
+	require.NoError(t, err)
+
+	destDir := t.TempDir()
+	require.NoError(t, ExtractTar(&buf, destDir, ExtractConfig{}))
+
+	info, err := os.Lstat(filepath.Join(destDir, base, "pipe"))
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeNamedPipe, info.Mode().Type())
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
+
+func TestExtractTar_Device(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating device nodes requires root")
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeChar,
+		Name:     "null",
+		Mode:     0666,
+		Devmajor: 1,
+		Devminor: 3,
+	}))
+	require.NoError(t, tw.Close())
+
+	destDir := t.TempDir()
+	err := ExtractTar(&buf, destDir, ExtractConfig{Devices: true})
+	if errors.Is(err, unix.EPERM) {
+		t.Skip("mknod not permitted in this environment")
+	}
+	require.NoError(t, err)
+
+	var st unix.Stat_t
+	require.NoError(t, unix.Lstat(filepath.Join(destDir, "null"), &st))
+	assert.Equal(t, uint32(unix.S_IFCHR), uint32(st.Mode)&unix.S_IFMT)
+	assert.Equal(t, uint32(1), unix.Major(uint64(st.Rdev)))
+	assert.Equal(t, uint32(3), unix.Minor(uint64(st.Rdev)))
+}
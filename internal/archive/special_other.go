@@ -0,0 +1,29 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux && !darwin
+
+package archive
+
+import "archive/tar"
+
+// mknodSupported reports whether mknod can create special files on this platform
+const mknodSupported = false
+
+// mknod is not implemented on this platform
+func mknod(path string, header *tar.Header) error {
+	return errSpecialUnsupported
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSpecialFilePolicy(t *testing.T) {
+	for _, p := range []SpecialFilePolicy{SpecialStore, SpecialSkip, SpecialFail} {
+		got, err := ParseSpecialFilePolicy(p.String())
+		require.NoError(t, err)
+		assert.Equal(t, p, got)
+	}
+
+	got, err := ParseSpecialFilePolicy("SKIP")
+	require.NoError(t, err)
+	assert.Equal(t, SpecialSkip, got)
+
+	_, err = ParseSpecialFilePolicy("keep")
+	assert.Error(t, err)
+}
+
+func TestSpecialKind(t *testing.T) {
+	assert.Equal(t, "socket", specialKind(os.ModeSocket))
+	assert.Equal(t, "FIFO", specialKind(os.ModeNamedPipe))
+	assert.Equal(t, "character device", specialKind(os.ModeDevice|os.ModeCharDevice))
+	assert.Equal(t, "block device", specialKind(os.ModeDevice))
+	assert.Equal(t, "", specialKind(0644))
+	assert.Equal(t, "", specialKind(os.ModeDir))
+}
+
+func TestExtractTar_DeviceWithoutRoot(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeChar,
+		Name:     "null",
+		Mode:     0666,
+		Devmajor: 1,
+		Devminor: 3,
+	}))
+	require.NoError(t, tw.Close())
+
+	// Devices are skipped unless enabled
+	destDir := t.TempDir()
+	result, err := Extract(&buf, destDir, ExtractConfig{})
+	require.NoError(t, err)
+	_, err = os.Lstat(filepath.Join(destDir, "null"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, 0, result.Created, "skipped devices are not counted as created")
+}
@@ -23,124 +23,375 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/icemarkom/secure-backup/internal/common"
 )
 
+// CreateConfig holds configuration for archive creation
+type CreateConfig struct {
+	Filter          Filter            // Include/exclude patterns (empty = archive everything)
+	IgnoreFile      string            // Per-directory ignore file name, e.g. ".backupignore" (empty = disabled)
+	ExcludeCaches   bool              // Skip contents of directories tagged with a valid CACHEDIR.TAG
+	SpecialFiles    SpecialFilePolicy // Handling of devices, FIFOs and sockets
+	OneFileSystem   bool              // Do not descend into directories on other devices (mount points)
+	FailOnChange    bool              // Fail instead of recording files that change while being read
+	ContinueOnError bool              // Skip unreadable entries and record them in Result.Errors
+	Reproducible    bool              // Normalize headers and order so identical trees give identical archives
+	ClampMtime      time.Time         // Reproducible: later modification times are set to this (zero = keep)
+	ReadAhead       int64             // Memory budget in bytes for reading small files ahead (0 = sequential)
+}
+
+// Result summarizes a CreateTarSources run
+type Result struct {
+	BytesWritten  int64       // Raw file data bytes written (excludes tar headers and metadata)
+	SkippedMounts []string    // Mount points whose contents were skipped (OneFileSystem)
+	ChangedFiles  []string    // Files whose size or times changed while they were read
+	Errors        []FileError // Entries skipped as unreadable (ContinueOnError)
+	VanishedFiles []string    // Entries removed between the scan and archiving
+}
+
 // CreateTar creates a tar archive from the source directory and writes to the provided writer.
 // Returns the total raw file data bytes written (excluding tar headers and metadata).
-func CreateTar(sourcePath string, w io.Writer) (int64, error) {
-	// Resolve to absolute path
-	absPath, err := filepath.Abs(sourcePath)
-	if err != nil {
-		return 0, fmt.Errorf("failed to resolve absolute path: %w", err)
-	}
+func CreateTar(sourcePath string, w io.Writer, cfg CreateConfig) (int64, error) {
+	result, err := CreateTarSources([]string{sourcePath}, w, cfg)
+	return result.BytesWritten, err
+}
 
-	// Verify source exists (Lstat to avoid following if source itself is a symlink)
-	sourceInfo, err := os.Lstat(absPath)
+// CreateTarSources creates a single tar archive from several sources. Each source is
+// stored under its own top-level prefix (see SourcePrefixes).
+func CreateTarSources(sourcePaths []string, w io.Writer, cfg CreateConfig) (Result, error) {
+	scan, err := ScanSources(sourcePaths, cfg)
 	if err != nil {
-		return 0, fmt.Errorf("failed to stat source path: %w", err)
+		return Result{}, err
 	}
+	return WriteTar(w, scan)
+}
 
+// WriteTar writes the entries of a scan as a tar archive. Files that changed
+// since the scan are archived as they are now; entries that vanished are left
+// out and listed in Result.VanishedFiles. Hard links are tracked across sources.
+// With CreateConfig.ReadAhead, small files are read concurrently ahead of the
+// writer; the archive is the same either way.
+func WriteTar(w io.Writer, scan *Scan) (Result, error) {
 	tw := tar.NewWriter(w)
 	defer tw.Close()
 
-	// Base directory for relative paths
-	baseDir := filepath.Dir(absPath)
-	baseName := filepath.Base(absPath)
+	a := &archiver{w: w, tw: tw, cfg: scan.cfg, links: make(map[fileID]string)}
+	a.result.SkippedMounts = slices.Clone(scan.result.SkippedMounts)
+	a.result.Errors = slices.Clone(scan.result.Errors)
 
-	// Track total raw file data bytes (excludes tar headers)
-	var bytesWritten int64
+	ahead := newReadAhead(scan)
+	defer ahead.close()
 
-	// Walk the directory tree (WalkDir uses Lstat — does not follow symlinks)
-	walkErr := filepath.WalkDir(absPath, func(file string, d fs.DirEntry, err error) error {
+	for _, e := range scan.Entries {
+		pf := ahead.next(e)
+		err := a.writeEntry(scan.Path(e), e.Name, e.Mode, pf)
+		ahead.release(pf)
 		if err != nil {
-			return fmt.Errorf("walk error at %s: %w", file, err)
+			return a.result, err
 		}
+	}
 
-		// Compute relative path for archive
-		var relPath string
-		if sourceInfo.IsDir() {
-			relPath, err = filepath.Rel(baseDir, file)
-			if err != nil {
-				return fmt.Errorf("failed to get relative path: %w", err)
-			}
-		} else {
-			if file == absPath {
-				relPath = baseName
-			} else {
-				relPath = filepath.Base(file)
+	return a.result, nil
+}
+
+// SourcePrefixes returns the top-level archive prefix of each source (its base
+// name). Sources sharing a base name cannot be stored in the same archive, and
+// neither can a source inside another one, which would be archived twice.
+func SourcePrefixes(sourcePaths []string) ([]string, error) {
+	if len(sourcePaths) == 0 {
+		return nil, fmt.Errorf("no source paths")
+	}
+
+	absPaths := make([]string, len(sourcePaths))
+	for i, sourcePath := range sourcePaths {
+		absPath, err := filepath.Abs(sourcePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
+		}
+		for j, other := range absPaths[:i] {
+			if isWithin(other, absPath) || isWithin(absPath, other) {
+				return nil, fmt.Errorf("sources %s and %s overlap", sourcePaths[j], sourcePath)
 			}
 		}
+		absPaths[i] = absPath
+	}
 
-		// Handle symlinks before calling d.Info() (which would follow them)
-		if d.Type()&os.ModeSymlink != 0 {
-			linkTarget, err := os.Readlink(file)
-			if err != nil {
-				return fmt.Errorf("failed to read symlink %s: %w", file, err)
-			}
-			header := &tar.Header{
-				Typeflag: tar.TypeSymlink,
-				Name:     relPath,
-				Linkname: linkTarget,
-			}
-			if err := tw.WriteHeader(header); err != nil {
-				return fmt.Errorf("failed to write tar header for symlink %s: %w", file, err)
-			}
-			return nil
+	prefixes := make([]string, len(sourcePaths))
+	seen := make(map[string]string)
+	for i, sourcePath := range sourcePaths {
+		prefix := filepath.Base(absPaths[i])
+		if other, dup := seen[prefix]; dup {
+			return nil, fmt.Errorf("sources %s and %s would both be stored as %q", other, sourcePath, prefix)
 		}
+		seen[prefix] = sourcePath
+		prefixes[i] = prefix
+	}
+
+	return prefixes, nil
+}
+
+// isWithin reports whether path is dir or below it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// archiver holds the state of a single ScanSources or WriteTar run
+type archiver struct {
+	w      io.Writer // Underlying writer of tw, for entries tw cannot encode
+	tw     *tar.Writer
+	cfg    CreateConfig
+	result Result            // Bytes written and entries skipped or changed
+	links  map[fileID]string // First archive name of each multiply-linked inode
+	buf    []byte            // Copy buffer shared by all files (see copyBuffer)
+}
+
+// copyBuffer returns the buffer file data is copied through, allocated on
+// first use and reused for every later file
+func (a *archiver) copyBuffer() []byte {
+	if a.buf == nil {
+		a.buf = common.NewBuffer()
+	}
+	return a.buf
+}
+
+// fileID identifies an inode on a specific device
+type fileID struct {
+	dev uint64
+	ino uint64
+}
 
-		// Get file info for non-symlink entries
-		fi, err := d.Info()
+// writeEntry writes the tar header, and the data for regular files, of a single
+// filesystem entry. relPath is the entry's name inside the archive and mode its
+// mode from the scan. pf is the file's data if it was read ahead, or nil.
+func (a *archiver) writeEntry(file, relPath string, mode fs.FileMode, pf *prefetch) error {
+	// Regular files are opened first, so the header describes the file as it
+	// is read rather than as it was scanned
+	var f *os.File
+	var fi fs.FileInfo
+	if pf != nil {
+		fi = pf.info
+	} else if mode.IsRegular() {
+		var err error
+		f, err = os.Open(file)
+		if err != nil {
+			return a.readFailed(file, fmt.Errorf("failed to open file %s: %w", file, err))
+		}
+		defer f.Close()
+		if fi, err = f.Stat(); err != nil {
+			return a.unreadable(file, fmt.Errorf("failed to stat %s: %w", file, err))
+		}
+		if !fi.Mode().IsRegular() {
+			// Replaced by another kind of file since the scan
+			a.result.VanishedFiles = append(a.result.VanishedFiles, file)
+			return nil
+		}
+	} else {
+		// Other entries are stat'ed again as well, so entries removed or
+		// replaced since the scan are not archived from stale information. A
+		// symlink replaced by another file fails to be read as a link below.
+		current, err := os.Lstat(file)
 		if err != nil {
-			return fmt.Errorf("failed to get file info for %s: %w", file, err)
+			return a.readFailed(file, fmt.Errorf("failed to stat %s: %w", file, err))
+		}
+		if current.Mode().Type() != mode.Type() && mode&os.ModeSymlink == 0 {
+			a.result.VanishedFiles = append(a.result.VanishedFiles, file)
+			return nil
 		}
+		fi = current
+	}
 
-		// Create tar header from file info
-		header, err := tar.FileInfoHeader(fi, "")
+	// Symlinks are stored as links, never dereferenced
+	var linkTarget string
+	if mode&os.ModeSymlink != 0 {
+		var err error
+		linkTarget, err = os.Readlink(file)
 		if err != nil {
-			return fmt.Errorf("failed to create tar header for %s: %w", file, err)
+			return a.readFailed(file, fmt.Errorf("failed to read symlink %s: %w", file, err))
 		}
-		header.Name = relPath
+	}
+
+	// Create tar header from file info (includes uid/gid and user/group names)
+	header, err := tar.FileInfoHeader(fi, linkTarget)
+	if err != nil {
+		return fmt.Errorf("failed to create tar header for %s: %w", file, err)
+	}
+	header.Name = relPath
+
+	// PAX keeps sub-second modification times and access times
+	header.Format = tar.FormatPAX
+	if a.cfg.Reproducible {
+		a.normalizeHeader(header)
+	}
+
+	// Later occurrences of a hard-linked inode are stored as links to the first
+	var linkID *fileID
+	if fi.Mode().IsRegular() {
+		if id, nlink, ok := fileIdentity(fi); ok && nlink > 1 {
+			if first, seen := a.links[id]; seen {
+				header.Typeflag = tar.TypeLink
+				header.Linkname = first
+				header.Size = 0
+				if err := a.tw.WriteHeader(header); err != nil {
+					return fmt.Errorf("failed to write tar header for hard link %s: %w", file, err)
+				}
+				return nil
+			}
+			linkID = &id
+		}
+	}
 
-		// Write header
-		if err := tw.WriteHeader(header); err != nil {
+	// Capture extended attributes (ACLs, SELinux labels, capabilities)
+	if err := addXattrs(header, file); err != nil {
+		return a.readFailed(file, err)
+	}
+
+	if !fi.Mode().IsRegular() {
+		if err := a.tw.WriteHeader(header); err != nil {
 			return fmt.Errorf("failed to write tar header for %s: %w", file, err)
 		}
+		return nil
+	}
 
-		// Write file data (if it's a regular file)
-		if fi.Mode().IsRegular() {
-			f, err := os.Open(file)
-			if err != nil {
-				return fmt.Errorf("failed to open file %s: %w", file, err)
-			}
-			defer f.Close()
+	if pf != nil {
+		if linkID != nil {
+			a.links[*linkID] = relPath
+		}
+		return a.writeBuffered(file, header, pf)
+	}
 
-			n, err := io.CopyBuffer(tw, f, common.NewBuffer())
-			if err != nil {
-				return fmt.Errorf("failed to write file data for %s: %w", file, err)
+	// Sparse files are stored without their holes. Holes depend on the file
+	// system, so reproducible archives store every file in full.
+	var regions []sparseRegion
+	if !a.cfg.Reproducible {
+		regions, err = dataRegions(f, fi)
+		if err != nil {
+			return a.unreadable(file, fmt.Errorf("failed to read holes of %s: %w", file, err))
+		}
+	}
+
+	// From here on the entry is written, so later hard links can refer to it.
+	// A read failure past this point leaves it zero-filled.
+	if linkID != nil {
+		a.links[*linkID] = relPath
+	}
+
+	return a.writeFile(file, f, header, fi, regions)
+}
+
+// writeFile writes a regular file's header and data. before is the FileInfo
+// the header was built from; changes after it are detected by checkUnchanged.
+// Files with holes (see dataRegions) are stored sparse.
+func (a *archiver) writeFile(file string, f *os.File, header *tar.Header, before fs.FileInfo, regions []sparseRegion) error {
+	if isSparse(regions, before.Size()) {
+		if err := a.writeSparse(header, f, regions); err != nil {
+			if isReadError(err) {
+				return a.unreadable(file, err)
 			}
-			bytesWritten += n
+			return err
 		}
+		return a.checkUnchanged(file, f, before)
+	}
 
-		return nil
-	})
+	if err := a.tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", file, err)
+	}
+
+	n, err := copyFileData(a.tw, f, header.Size, a.copyBuffer())
+	a.result.BytesWritten += n
+	if isReadError(err) {
+		return a.unreadable(file, fmt.Errorf("failed to read file %s: %w", file, err))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file data for %s: %w", file, err)
+	}
+
+	return a.checkUnchanged(file, f, before)
+}
+
+// writeBuffered writes a regular file's header and the data read ahead by pf.
+// Changes while it was read are detected as in writeFile.
+func (a *archiver) writeBuffered(file string, header *tar.Header, pf *prefetch) error {
+	if err := a.tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", file, err)
+	}
+	if _, err := a.tw.Write(pf.data); err != nil {
+		return fmt.Errorf("failed to write file data for %s: %w", file, err)
+	}
+	a.result.BytesWritten += pf.n
 
-	return bytesWritten, walkErr
+	return a.recordChange(file, pf.info, pf.after)
+}
+
+// ExtractConfig holds configuration for archive extraction
+type ExtractConfig struct {
+	SameOwner    bool        // Restore archived ownership (normally requires root)
+	NumericOwner bool        // Use archived uid/gid only, ignore user/group names
+	UIDMap       map[int]int // Remap archived UIDs (takes precedence over name lookup)
+	GIDMap       map[int]int // Remap archived GIDs (takes precedence over name lookup)
+	Xattrs       bool        // Restore extended attributes (ACLs, SELinux labels, capabilities)
+	Devices      bool        // Recreate device nodes (requires root; FIFOs are always recreated)
+	Paths        []string    // Only extract these archive paths and everything below them (empty = all)
+	Filter       Filter      // Include/exclude patterns applied to archive paths
+
+	// Rewrite entry names after selection: strip leading components first, then relocate
+	StripComponents int
+	Relocate        []Relocation
+
+	OnConflict ConflictPolicy // What to do when an entry's target path already exists
+
+	Mirror   bool // Record target paths in ExtractResult.Targets (see Extraneous)
+	ListOnly bool // Select and rewrite entries without writing anything
+}
+
+// ExtractResult summarizes an Extract run
+type ExtractResult struct {
+	Entries  int // Entries read from the archive
+	Selected int // Entries matching ExtractConfig.Paths and Filter and not stripped away
+
+	// Outcome of non-directory entries under ExtractConfig.OnConflict
+	Created     int // Written to a path that did not exist
+	Overwritten int // Replaced an existing file
+	Skipped     int // Left an existing file in place
+	Renamed     int // Written next to an existing file (ConflictRename)
+
+	Targets map[string]bool // Absolute destination paths of all selected entries (with Mirror)
 }
 
 // ExtractTar extracts a tar archive from the reader to the destination directory
-func ExtractTar(r io.Reader, destPath string) error {
+func ExtractTar(r io.Reader, destPath string, cfg ExtractConfig) error {
+	_, err := Extract(r, destPath, cfg)
+	return err
+}
+
+// Extract extracts a tar archive from the reader to the destination directory
+// and reports how many entries were read and selected
+func Extract(r io.Reader, destPath string, cfg ExtractConfig) (ExtractResult, error) {
 	// Ensure destination directory exists
-	if err := os.MkdirAll(destPath, 0755); err != nil {
-		return fmt.Errorf("failed to create destination directory: %w", err)
+	if !cfg.ListOnly {
+		if err := os.MkdirAll(destPath, 0755); err != nil {
+			return ExtractResult{}, fmt.Errorf("failed to create destination directory: %w", err)
+		}
 	}
 
 	// Resolve to absolute path for security
 	absDestPath, err := filepath.Abs(destPath)
 	if err != nil {
-		return fmt.Errorf("failed to resolve destination path: %w", err)
+		return ExtractResult{}, fmt.Errorf("failed to resolve destination path: %w", err)
+	}
+
+	x := &extractor{
+		cfg:      cfg,
+		destPath: absDestPath,
+		owners:   newOwnerResolver(cfg),
+		renamed:  make(map[string]string),
+	}
+	if cfg.Mirror {
+		x.result.Targets = make(map[string]bool)
 	}
 
 	tr := tar.NewReader(r)
@@ -151,67 +402,243 @@ func ExtractTar(r io.Reader, destPath string) error {
 			break // End of archive
 		}
 		if err != nil {
-			return fmt.Errorf("failed to read tar header: %w", err)
+			return x.result, fmt.Errorf("failed to read tar header: %w", err)
 		}
+		x.result.Entries++
 
-		// Sanitize the file path to prevent path traversal attacks
-		if err := validateTarPath(header.Name); err != nil {
-			return fmt.Errorf("invalid tar path %s: %w", header.Name, err)
+		// Entries outside the selection are skipped without touching the disk
+		if !x.selected(header) {
+			continue
 		}
+		linkSelected := x.linkTargetSelected(header)
+		if !x.rewriteHeader(header) {
+			continue
+		}
+		x.result.Selected++
 
-		// Construct full destination path
-		targetPath := filepath.Join(absDestPath, header.Name)
+		if cfg.Mirror {
+			x.recordTarget(header.Name)
+		}
+		if cfg.ListOnly {
+			continue
+		}
+
+		// Linking to a file on disk that was not restored would give the link
+		// live contents instead of the backup's, so any existing file is kept
+		if !linkSelected {
+			fmt.Fprintf(os.Stderr, "Warning: skipping hard link %s: target %s is not part of the selection\n", header.Name, header.Linkname)
+			continue
+		}
 
-		// Security check: ensure the target path is within destination
-		if !strings.HasPrefix(targetPath, absDestPath+string(os.PathSeparator)) &&
-			targetPath != absDestPath {
-			return fmt.Errorf("invalid tar path %s: path traversal detected", header.Name)
+		if err := x.extractEntry(tr, header); err != nil {
+			return x.result, err
 		}
+	}
+
+	// Directory modes and times are applied once all entries are written
+	return x.result, x.finishDirectories()
+}
+
+// extractor holds the state of a single ExtractTar run
+type extractor struct {
+	cfg      ExtractConfig
+	destPath string // Absolute destination directory
+	owners   *ownerResolver
+	dirs     []deferredDir     // Directories awaiting mode and time restoration
+	renamed  map[string]string // Target paths moved aside by ConflictRename
+	result   ExtractResult
+}
 
-		// Extract based on type
-		switch header.Typeflag {
-		case tar.TypeDir:
-			// Create directory
-			if err := os.MkdirAll(targetPath, os.FileMode(header.Mode)); err != nil {
+// extractEntry extracts a single tar entry, reading file data from r
+func (x *extractor) extractEntry(r io.Reader, header *tar.Header) error {
+	absDestPath := x.destPath
+
+	// Sanitize the file path to prevent path traversal attacks
+	if err := validateTarPath(header.Name); err != nil {
+		return fmt.Errorf("invalid tar path %s: %w", header.Name, err)
+	}
+
+	// Construct full destination path
+	targetPath := filepath.Join(absDestPath, header.Name)
+
+	// Security check: ensure the target path is within destination
+	if !strings.HasPrefix(targetPath, absDestPath+string(os.PathSeparator)) &&
+		targetPath != absDestPath {
+		return fmt.Errorf("invalid tar path %s: path traversal detected", header.Name)
+	}
+
+	// Security check: symlinks extracted earlier must not lead outside the
+	// destination. Directories are checked in full, other entries up to their
+	// parent (an existing symlink in their place is replaced, not followed).
+	relPath, err := filepath.Rel(absDestPath, targetPath)
+	if err != nil {
+		return fmt.Errorf("invalid tar path %s: %w", header.Name, err)
+	}
+	if header.Typeflag != tar.TypeDir {
+		relPath = filepath.Dir(relPath)
+	}
+	if err := checkBeneath(absDestPath, relPath); err != nil {
+		return fmt.Errorf("invalid tar path %s: %w", header.Name, err)
+	}
+
+	// Hard link targets are checked before anything at the destination changes
+	var linkTarget string
+	if header.Typeflag == tar.TypeLink {
+		if linkTarget, err = x.resolveLinkTarget(header); err != nil {
+			return err
+		}
+	}
+
+	// Entries that cannot be restored are skipped before the conflict policy
+	// removes an existing file in their place
+	if !x.restorable(header, linkTarget) {
+		return nil
+	}
+
+	// Existing directories are merged; other existing files follow the conflict policy
+	var outcome conflictOutcome
+	if header.Typeflag != tar.TypeDir {
+		targetPath, outcome, err = x.resolveConflict(header, targetPath)
+		if err != nil || targetPath == "" {
+			return err
+		}
+	}
+
+	// Extract based on type
+	switch header.Typeflag {
+	case tar.TypeDir:
+		// Only overwrite replaces the metadata of directories that already exist
+		if info, err := os.Lstat(targetPath); err == nil && info.IsDir() && x.cfg.OnConflict != ConflictOverwrite {
+			return nil
+		}
+
+		// Missing parents have no archive entry whose mode could be applied
+		// later, so they are created like the parents of files
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			return fmt.Errorf("failed to create parent directory for %s: %w", targetPath, err)
+		}
+
+		// Create directory owner-writable so its contents can be extracted;
+		// the archived mode is applied by finishDirectories
+		if err := os.Mkdir(targetPath, 0700); err != nil {
+			if info, statErr := os.Stat(targetPath); statErr != nil || !info.IsDir() {
 				return fmt.Errorf("failed to create directory %s: %w", targetPath, err)
 			}
+		}
 
-		case tar.TypeReg:
-			// Create parent directory if needed
-			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				return fmt.Errorf("failed to create parent directory for %s: %w", targetPath, err)
-			}
+	case tar.TypeReg, tar.TypeGNUSparse:
+		// Create parent directory if needed
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			return fmt.Errorf("failed to create parent directory for %s: %w", targetPath, err)
+		}
 
-			// Create file
-			outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
-			if err != nil {
-				return fmt.Errorf("failed to create file %s: %w", targetPath, err)
-			}
+		// Create file
+		outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+		if err != nil {
+			return fmt.Errorf("failed to create file %s: %w", targetPath, err)
+		}
 
-			if _, err := io.CopyBuffer(outFile, tr, common.NewBuffer()); err != nil {
-				outFile.Close()
-				return fmt.Errorf("failed to write file %s: %w", targetPath, err)
-			}
+		// Sparse entries are written with holes instead of zeros
+		if isSparseHeader(header) {
+			_, err = writeSparseFile(outFile, r, header.Size)
+		} else {
+			_, err = io.CopyBuffer(outFile, r, common.NewBuffer())
+		}
+		if err != nil {
 			outFile.Close()
+			return fmt.Errorf("failed to write file %s: %w", targetPath, err)
+		}
+		outFile.Close()
+		x.countWritten(outcome)
 
-		case tar.TypeSymlink:
-			// Create parent directory if needed
-			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				return fmt.Errorf("failed to create parent directory for %s: %w", targetPath, err)
-			}
+	case tar.TypeLink:
+		// Create parent directory if needed
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			return fmt.Errorf("failed to create parent directory for %s: %w", targetPath, err)
+		}
 
-			// Create symlink
-			if err := os.Symlink(header.Linkname, targetPath); err != nil {
-				return fmt.Errorf("failed to create symlink %s: %w", targetPath, err)
+		// Replace an existing file (os.Link does not overwrite)
+		if info, err := os.Lstat(targetPath); err == nil && !info.IsDir() {
+			if err := os.Remove(targetPath); err != nil {
+				return fmt.Errorf("failed to replace %s: %w", targetPath, err)
 			}
+		}
+
+		if err := os.Link(linkTarget, targetPath); err != nil {
+			return fmt.Errorf("failed to create hard link %s: %w", targetPath, err)
+		}
+		x.countWritten(outcome)
+
+		// The linked inode already carries ownership, mode, attributes and times
+		return nil
+
+	case tar.TypeSymlink:
+		// Create parent directory if needed
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			return fmt.Errorf("failed to create parent directory for %s: %w", targetPath, err)
+		}
+
+		// Create symlink
+		if err := os.Symlink(header.Linkname, targetPath); err != nil {
+			return fmt.Errorf("failed to create symlink %s: %w", targetPath, err)
+		}
+		x.countWritten(outcome)
 
-		default:
-			// Skip unsupported types (block devices, character devices, etc.)
-			fmt.Fprintf(os.Stderr, "Warning: skipping unsupported file type %c for %s\n", header.Typeflag, header.Name)
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		if err := x.createSpecial(header, targetPath); err != nil {
+			return err
 		}
+		x.countWritten(outcome)
 	}
 
-	return nil
+	return x.applyMetadata(header, targetPath)
+}
+
+// resolveLinkTarget returns the destination path a hard link entry links to,
+// validated the same way as entry names
+func (x *extractor) resolveLinkTarget(header *tar.Header) (string, error) {
+	if err := validateTarPath(header.Linkname); err != nil {
+		return "", fmt.Errorf("invalid hard link target %s: %w", header.Linkname, err)
+	}
+	linkTarget := filepath.Join(x.destPath, header.Linkname)
+	if renamed, ok := x.renamed[linkTarget]; ok {
+		linkTarget = renamed // Link to the restored copy, not the existing file
+	}
+	if !strings.HasPrefix(linkTarget, x.destPath+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid hard link target %s: path traversal detected", header.Linkname)
+	}
+	relLink, err := filepath.Rel(x.destPath, linkTarget)
+	if err != nil {
+		return "", fmt.Errorf("invalid hard link target %s: %w", header.Linkname, err)
+	}
+	if err := checkBeneath(x.destPath, relLink); err != nil {
+		return "", fmt.Errorf("invalid hard link target %s: %w", header.Linkname, err)
+	}
+	return linkTarget, nil
+}
+
+// restorable reports whether an entry can be written, warning about entries
+// that are skipped. Nothing at the destination is changed for skipped entries.
+func (x *extractor) restorable(header *tar.Header, linkTarget string) bool {
+	switch header.Typeflag {
+	case tar.TypeDir, tar.TypeReg, tar.TypeGNUSparse, tar.TypeSymlink:
+		return true
+
+	case tar.TypeLink:
+		// A selected link target may still have been skipped (see restorable)
+		if _, err := os.Lstat(linkTarget); os.IsNotExist(err) && x.selective() {
+			fmt.Fprintf(os.Stderr, "Warning: skipping hard link %s: target %s was not restored\n", header.Name, header.Linkname)
+			return false
+		}
+		return true
+
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		return x.specialRestorable(header)
+
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: skipping unsupported file type %c for %s\n", header.Typeflag, header.Name)
+		return false
+	}
 }
 
 // validateTarPath checks for path traversal attempts in tar archive paths
@@ -0,0 +1,64 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestCreateTar_OneFileSystem(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("mounting a test file system requires root")
+	}
+
+	srcDir := t.TempDir()
+	base := filepath.Base(srcDir)
+	mnt := filepath.Join(srcDir, "mnt")
+	require.NoError(t, os.Mkdir(mnt, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "local.txt"), []byte("local"), 0644))
+
+	if err := unix.Mount("tmpfs", mnt, "tmpfs", 0, "size=1m"); err != nil {
+		t.Skipf("cannot mount tmpfs: %v", err)
+	}
+	t.Cleanup(func() { unix.Unmount(mnt, 0) })
+	require.NoError(t, os.WriteFile(filepath.Join(mnt, "remote.txt"), []byte("remote"), 0644))
+
+	// Default: the mount is crossed
+	var buf bytes.Buffer
+	result, err := CreateTarSources([]string{srcDir}, &buf, CreateConfig{})
+	require.NoError(t, err)
+	assert.Contains(t, listTarEntries(t, buf.Bytes()), filepath.Join(base, "mnt", "remote.txt"))
+	assert.Empty(t, result.SkippedMounts)
+
+	// One file system: the mount point is kept empty and reported
+	buf.Reset()
+	result, err = CreateTarSources([]string{srcDir}, &buf, CreateConfig{OneFileSystem: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		base,
+		filepath.Join(base, "local.txt"),
+		filepath.Join(base, "mnt"),
+	}, listTarEntries(t, buf.Bytes()))
+	assert.Equal(t, []string{mnt}, result.SkippedMounts)
+}
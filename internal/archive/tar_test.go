@@ -17,9 +17,12 @@
 package archive
 
 import (
+	"archive/tar"
 	"bytes"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -37,7 +40,7 @@ func TestCreateTar_SingleFile(t *testing.T) {
 
 	// Create tar
 	var buf bytes.Buffer
-	bytesWritten, err := CreateTar(testFile, &buf)
+	bytesWritten, err := CreateTar(testFile, &buf, CreateConfig{})
 	require.NoError(t, err)
 	assert.Equal(t, int64(len(testContent)), bytesWritten)
 
@@ -66,7 +69,7 @@ func TestCreateTar_Directory(t *testing.T) {
 
 	// Create tar
 	var buf bytes.Buffer
-	bytesWritten, err := CreateTar(tmpDir, &buf)
+	bytesWritten, err := CreateTar(tmpDir, &buf, CreateConfig{})
 	require.NoError(t, err)
 
 	// 3 files: "content1" (8) + "content2" (8) + "content3" (8) = 24 bytes
@@ -78,7 +81,7 @@ func TestCreateTar_Directory(t *testing.T) {
 
 func TestCreateTar_InvalidPath(t *testing.T) {
 	var buf bytes.Buffer
-	_, err := CreateTar("/nonexistent/path/that/does/not/exist", &buf)
+	_, err := CreateTar("/nonexistent/path/that/does/not/exist", &buf, CreateConfig{})
 	assert.Error(t, err)
 }
 
@@ -112,12 +115,12 @@ func TestExtractTar_RoundTrip(t *testing.T) {
 
 	// Create tar
 	var buf bytes.Buffer
-	_, err = CreateTar(srcDir, &buf)
+	_, err = CreateTar(srcDir, &buf, CreateConfig{})
 	require.NoError(t, err)
 
 	// Extract to new directory
 	destDir := t.TempDir()
-	err = ExtractTar(&buf, destDir)
+	err = ExtractTar(&buf, destDir, ExtractConfig{})
 	require.NoError(t, err)
 
 	// Verify extracted files
@@ -155,7 +158,7 @@ func TestExtractTar_PathTraversal(t *testing.T) {
 
 	// Create a malicious tar with path traversal attempt
 	// Note: This is a simplified test - real implementation should reject these
-	err := ExtractTar(bytes.NewReader([]byte{}), destDir)
+	err := ExtractTar(bytes.NewReader([]byte{}), destDir, ExtractConfig{})
 	// Empty tar should not error
 	assert.NoError(t, err)
 }
@@ -172,11 +175,11 @@ func TestExtractTar_CreateDestination(t *testing.T) {
 	require.NoError(t, err)
 
 	var buf bytes.Buffer
-	_, err = CreateTar(testFile, &buf)
+	_, err = CreateTar(testFile, &buf, CreateConfig{})
 	require.NoError(t, err)
 
 	// Extract to nonexistent path
-	err = ExtractTar(&buf, destDir)
+	err = ExtractTar(&buf, destDir, ExtractConfig{})
 	require.NoError(t, err)
 
 	// Verify destination was created
@@ -234,7 +237,7 @@ func TestCreateTar_Symlink(t *testing.T) {
 
 	// Create tar
 	var buf bytes.Buffer
-	bytesWritten, err := CreateTar(tmpDir, &buf)
+	bytesWritten, err := CreateTar(tmpDir, &buf, CreateConfig{})
 	require.NoError(t, err)
 
 	// Only target.txt has file data (14 bytes), symlink has 0 data bytes
@@ -242,7 +245,7 @@ func TestCreateTar_Symlink(t *testing.T) {
 
 	// Extract
 	destDir := t.TempDir()
-	err = ExtractTar(&buf, destDir)
+	err = ExtractTar(&buf, destDir, ExtractConfig{})
 	require.NoError(t, err)
 
 	// Verify symlink was preserved as a symlink (not dereferenced)
@@ -277,12 +280,12 @@ func TestCreateTar_SymlinkToExternal(t *testing.T) {
 
 	// Create tar
 	var buf bytes.Buffer
-	_, err = CreateTar(srcDir, &buf)
+	_, err = CreateTar(srcDir, &buf, CreateConfig{})
 	require.NoError(t, err)
 
 	// Extract and verify the external file content was NOT included
 	destDir := t.TempDir()
-	err = ExtractTar(&buf, destDir)
+	err = ExtractTar(&buf, destDir, ExtractConfig{})
 	require.NoError(t, err)
 
 	srcBase := filepath.Base(srcDir)
@@ -319,12 +322,12 @@ func TestCreateTar_SymlinkRoundTrip(t *testing.T) {
 
 	// Backup
 	var buf bytes.Buffer
-	_, err = CreateTar(srcDir, &buf)
+	_, err = CreateTar(srcDir, &buf, CreateConfig{})
 	require.NoError(t, err)
 
 	// Restore
 	destDir := t.TempDir()
-	err = ExtractTar(&buf, destDir)
+	err = ExtractTar(&buf, destDir, ExtractConfig{})
 	require.NoError(t, err)
 
 	srcBase := filepath.Base(srcDir)
@@ -346,7 +349,186 @@ func TestExtractTar_EmptyArchive(t *testing.T) {
 	// Create empty tar (just EOF)
 	var buf bytes.Buffer
 
-	err := ExtractTar(&buf, destDir)
+	err := ExtractTar(&buf, destDir, ExtractConfig{})
 	// Empty tar should complete without error
 	assert.NoError(t, err)
 }
+
+// listTarEntries returns the entry names of a tar stream in archive order
+func listTarEntries(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	return names
+}
+
+func TestCreateTar_ExcludePatterns(t *testing.T) {
+	srcDir := t.TempDir()
+	base := filepath.Base(srcDir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "keep.txt"), []byte("keep"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "scratch.tmp"), []byte("tmp"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "node_modules", "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "node_modules", "pkg", "index.js"), []byte("js"), 0644))
+
+	var buf bytes.Buffer
+	bytesWritten, err := CreateTar(srcDir, &buf, CreateConfig{
+		Filter: Filter{Excludes: []string{"*.tmp", "node_modules"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("keep")), bytesWritten)
+
+	got := listTarEntries(t, buf.Bytes())
+	assert.Equal(t, []string{base, filepath.Join(base, "keep.txt")}, got)
+}
+
+func TestCreateTar_IncludePatterns(t *testing.T) {
+	srcDir := t.TempDir()
+	base := filepath.Base(srcDir)
+
+	require.NoError(t, os.Mkdir(filepath.Join(srcDir, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "src", "main.go"), []byte("package main"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "README.md"), []byte("readme"), 0644))
+
+	var buf bytes.Buffer
+	_, err := CreateTar(srcDir, &buf, CreateConfig{
+		Filter: Filter{Includes: []string{"*.go"}},
+	})
+	require.NoError(t, err)
+
+	got := listTarEntries(t, buf.Bytes())
+	assert.Equal(t, []string{base, filepath.Join(base, "src"), filepath.Join(base, "src", "main.go")}, got)
+}
+
+func TestCreateTar_HardLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Hard link detection not supported on Windows")
+	}
+
+	srcDir := t.TempDir()
+	base := filepath.Base(srcDir)
+	content := "shared content"
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte(content), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(srcDir, "sub"), 0755))
+	require.NoError(t, os.Link(filepath.Join(srcDir, "a.txt"), filepath.Join(srcDir, "sub", "b.txt")))
+
+	var buf bytes.Buffer
+	bytesWritten, err := CreateTar(srcDir, &buf, CreateConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), bytesWritten, "hard-linked data should be stored once")
+
+	// Second occurrence is a TypeLink pointing at the first
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	var link *tar.Header
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if header.Typeflag == tar.TypeLink {
+			link = header
+		}
+	}
+	require.NotNil(t, link)
+	assert.Equal(t, filepath.Join(base, "sub", "b.txt"), link.Name)
+	assert.Equal(t, filepath.Join(base, "a.txt"), link.Linkname)
+
+	// Extraction recreates the link
+	destDir := t.TempDir()
+	require.NoError(t, ExtractTar(bytes.NewReader(buf.Bytes()), destDir, ExtractConfig{}))
+
+	infoA, err := os.Stat(filepath.Join(destDir, base, "a.txt"))
+	require.NoError(t, err)
+	infoB, err := os.Stat(filepath.Join(destDir, base, "sub", "b.txt"))
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(infoA, infoB), "expected restored files to share an inode")
+
+	got, err := os.ReadFile(filepath.Join(destDir, base, "sub", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, content, string(got))
+}
+
+func TestExtractTar_HardLinkTraversal(t *testing.T) {
+	tests := []struct {
+		name     string
+		linkname string
+	}{
+		{"absolute target", "/etc/passwd"},
+		{"parent traversal", "../outside.txt"},
+		{"embedded traversal", "data/../../outside.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeLink, Name: "data/link", Linkname: tt.linkname}))
+			require.NoError(t, tw.Close())
+
+			destDir := t.TempDir()
+			err := ExtractTar(&buf, destDir, ExtractConfig{})
+			assert.Error(t, err)
+
+			_, statErr := os.Lstat(filepath.Join(destDir, "data", "link"))
+			assert.True(t, os.IsNotExist(statErr), "hard link must not be created")
+		})
+	}
+}
+
+func TestCreateTarSources(t *testing.T) {
+	root := t.TempDir()
+	etc := filepath.Join(root, "etc")
+	app := filepath.Join(root, "var", "lib", "app")
+	require.NoError(t, os.MkdirAll(etc, 0755))
+	require.NoError(t, os.MkdirAll(app, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(etc, "hosts"), []byte("hosts"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(app, "state"), []byte("state"), 0644))
+
+	var buf bytes.Buffer
+	result, err := CreateTarSources([]string{etc, app}, &buf, CreateConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("hosts")+len("state")), result.BytesWritten)
+
+	assert.Equal(t, []string{
+		"etc",
+		filepath.Join("etc", "hosts"),
+		"app",
+		filepath.Join("app", "state"),
+	}, listTarEntries(t, buf.Bytes()))
+
+	// Sources with the same base name are rejected before anything is written
+	buf.Reset()
+	other := filepath.Join(root, "usr", "etc")
+	require.NoError(t, os.MkdirAll(other, 0755))
+	_, err = CreateTarSources([]string{etc, other}, &buf, CreateConfig{})
+	assert.Error(t, err)
+	assert.Zero(t, buf.Len())
+
+	// Nested and repeated sources are rejected, as they would be archived twice
+	home := filepath.Join(root, "home")
+	alice := filepath.Join(home, "alice")
+	require.NoError(t, os.MkdirAll(alice, 0755))
+	for _, sources := range [][]string{{home, alice}, {alice, home}, {etc, etc}, {etc, etc + string(os.PathSeparator)}} {
+		_, err = CreateTarSources(sources, &buf, CreateConfig{})
+		assert.ErrorContains(t, err, "overlap", "%v", sources)
+		assert.Zero(t, buf.Len())
+	}
+	_, err = SourcePrefixes([]string{home, home + "work"})
+	assert.NoError(t, err, "a shared name prefix is not nesting")
+
+	// Missing sources are rejected before anything is written
+	_, err = CreateTarSources([]string{etc, filepath.Join(root, "missing")}, &buf, CreateConfig{})
+	assert.Error(t, err)
+	assert.Zero(t, buf.Len())
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// FileError records a source entry that could not be read
+type FileError struct {
+	Path string // Absolute path of the entry
+	Err  error
+}
+
+// readError is a failure to read a file's data, as opposed to a failure to
+// write the archive
+type readError struct {
+	err error
+}
+
+func (e *readError) Error() string { return e.err.Error() }
+func (e *readError) Unwrap() error { return e.err }
+
+// isReadError reports whether err came from reading source data
+func isReadError(err error) bool {
+	var re *readError
+	return errors.As(err, &re)
+}
+
+// unreadable handles an entry that could not be read. With
+// CreateConfig.ContinueOnError the entry is recorded in the result and
+// archiving continues; otherwise err aborts the archive.
+func (a *archiver) unreadable(file string, err error) error {
+	if !a.cfg.ContinueOnError {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Warning: skipping unreadable %s: %v\n", file, err)
+	a.result.Errors = append(a.result.Errors, FileError{Path: file, Err: err})
+	return nil
+}
+
+// readFailed handles an entry that could not be read while writing the
+// archive. Entries removed since the scan are left out and listed in
+// Result.VanishedFiles; other failures are unreadable.
+func (a *archiver) readFailed(file string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		a.result.VanishedFiles = append(a.result.VanishedFiles, file)
+		return nil
+	}
+	return a.unreadable(file, err)
+}
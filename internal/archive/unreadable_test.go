@@ -0,0 +1,212 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDevice = errors.New("input/output error")
+
+// failingReader returns data, then errDevice
+type failingReader struct {
+	data []byte
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errDevice
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestCopyFileData_ReadError(t *testing.T) {
+	var out bytes.Buffer
+	n, err := copyFileData(&out, &failingReader{data: []byte("abc")}, 6, make([]byte, 4))
+
+	assert.True(t, isReadError(err))
+	assert.ErrorIs(t, err, errDevice)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, "abc\x00\x00\x00", out.String(), "the rest of the entry is zero-filled")
+}
+
+func TestCopyFileData_WriteError(t *testing.T) {
+	_, err := copyFileData(failingWriter{}, bytes.NewReader([]byte("abc")), 3, make([]byte, 4))
+
+	require.Error(t, err)
+	assert.False(t, isReadError(err))
+}
+
+// vanishedInfo returns the FileInfo of path, then removes the file as if it
+// disappeared between the scan and archiving
+func vanishedInfo(t *testing.T, path string) fs.FileInfo {
+	t.Helper()
+	info, err := os.Lstat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(path))
+	return info
+}
+
+func TestWriteEntry_Unreadable(t *testing.T) {
+	for _, continueOnError := range []bool{false, true} {
+		// A symlink at scan time that is a regular file now cannot be read as a link
+		path := filepath.Join(t.TempDir(), "link")
+		require.NoError(t, os.Symlink("target", path))
+		info := vanishedInfo(t, path)
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+
+		var buf bytes.Buffer
+		a := &archiver{w: &buf, tw: tar.NewWriter(&buf), cfg: CreateConfig{ContinueOnError: continueOnError}, links: make(map[fileID]string)}
+		err := a.writeEntry(path, "link", info.Mode(), nil)
+
+		if !continueOnError {
+			require.Error(t, err)
+			assert.Empty(t, a.result.Errors)
+			continue
+		}
+		require.NoError(t, err)
+		require.Len(t, a.result.Errors, 1)
+		assert.Equal(t, path, a.result.Errors[0].Path)
+
+		require.NoError(t, a.tw.Close())
+		assert.Empty(t, listTarEntries(t, buf.Bytes()), "nothing is written for a skipped entry")
+	}
+}
+
+func TestWriteEntry_Vanished(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "gone.txt")
+	require.NoError(t, os.WriteFile(file, []byte("data"), 0644))
+	link := filepath.Join(dir, "gone.lnk")
+	require.NoError(t, os.Symlink("gone.txt", link))
+	subdir := filepath.Join(dir, "gone.d")
+	require.NoError(t, os.Mkdir(subdir, 0755))
+	fileInfo := vanishedInfo(t, file)
+	linkInfo := vanishedInfo(t, link)
+	dirInfo := vanishedInfo(t, subdir)
+
+	// A directory replaced by a file is not archived from its scan information
+	replaced := filepath.Join(dir, "replaced")
+	require.NoError(t, os.Mkdir(replaced, 0755))
+	replacedInfo := vanishedInfo(t, replaced)
+	require.NoError(t, os.WriteFile(replaced, []byte("data"), 0644))
+
+	// Vanished entries are not errors, with or without ContinueOnError
+	var buf bytes.Buffer
+	a := &archiver{w: &buf, tw: tar.NewWriter(&buf), links: make(map[fileID]string)}
+	require.NoError(t, a.writeEntry(file, "gone.txt", fileInfo.Mode(), nil))
+	require.NoError(t, a.writeEntry(link, "gone.lnk", linkInfo.Mode(), nil))
+	require.NoError(t, a.writeEntry(subdir, "gone.d", dirInfo.Mode(), nil))
+	require.NoError(t, a.writeEntry(replaced, "replaced", replacedInfo.Mode(), nil))
+	require.NoError(t, a.tw.Close())
+
+	assert.Equal(t, []string{file, link, subdir, replaced}, a.result.VanishedFiles)
+	assert.Empty(t, a.result.Errors)
+	assert.Empty(t, listTarEntries(t, buf.Bytes()))
+}
+
+func TestWriteEntry_VanishedHardLink(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	require.NoError(t, os.WriteFile(first, []byte("shared"), 0644))
+	require.NoError(t, os.Link(first, second))
+
+	secondInfo, err := os.Lstat(second)
+	require.NoError(t, err)
+	firstInfo := vanishedInfo(t, first)
+
+	var buf bytes.Buffer
+	a := &archiver{w: &buf, tw: tar.NewWriter(&buf), links: make(map[fileID]string)}
+	require.NoError(t, a.writeEntry(first, "first", firstInfo.Mode(), nil))
+	require.NoError(t, a.writeEntry(second, "second", secondInfo.Mode(), nil))
+	require.NoError(t, a.tw.Close())
+
+	// The skipped first link must not become the target of the second
+	tr := tar.NewReader(&buf)
+	header, err := tr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "second", header.Name)
+	assert.Equal(t, byte(tar.TypeReg), header.Typeflag)
+	content, err := io.ReadAll(tr)
+	require.NoError(t, err)
+	assert.Equal(t, "shared", string(content))
+}
+
+func TestCreateTar_ContinueOnError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits do not deny reads on Windows")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("root can read files regardless of permissions")
+	}
+
+	srcDir := t.TempDir()
+	base := filepath.Base(srcDir)
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "ok.txt"), []byte("ok"), 0644))
+	secret := filepath.Join(srcDir, "secret.txt")
+	require.NoError(t, os.WriteFile(secret, []byte("secret"), 0000))
+	locked := filepath.Join(srcDir, "locked")
+	require.NoError(t, os.Mkdir(locked, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(locked, "inner.txt"), []byte("inner"), 0644))
+	require.NoError(t, os.Chmod(locked, 0000))
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+
+	// By default the first unreadable entry fails the archive
+	var buf bytes.Buffer
+	_, err := CreateTarSources([]string{srcDir}, &buf, CreateConfig{})
+	require.Error(t, err)
+
+	// With or without ignore files, which are read from each directory
+	for _, ignoreFile := range []string{"", DefaultIgnoreFile} {
+		buf.Reset()
+		result, err := CreateTarSources([]string{srcDir}, &buf, CreateConfig{ContinueOnError: true, IgnoreFile: ignoreFile})
+		require.NoError(t, err)
+
+		var skipped []string
+		for _, fe := range result.Errors {
+			skipped = append(skipped, fe.Path)
+		}
+		assert.ElementsMatch(t, []string{locked, secret}, skipped)
+
+		// Unreadable directories are kept empty; unreadable files are left out
+		assert.Equal(t, []string{
+			base,
+			filepath.Join(base, "locked"),
+			filepath.Join(base, "ok.txt"),
+		}, listTarEntries(t, buf.Bytes()))
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// paxXattrPrefix is the PAX record prefix for extended attributes, as used by
+// GNU tar, bsdtar and star. POSIX ACLs (system.posix_acl_*), SELinux labels
+// (security.selinux) and file capabilities (security.capability) are all
+// stored as extended attributes on Linux and round-trip through these records.
+const paxXattrPrefix = "SCHILY.xattr."
+
+// errXattrUnsupported is returned when the platform or filesystem cannot store xattrs
+var errXattrUnsupported = errors.New("extended attributes not supported")
+
+// addXattrs reads the extended attributes of path (without following symlinks)
+// and stores them as PAX records on the header
+func addXattrs(header *tar.Header, path string) error {
+	xattrs, err := readXattrs(path)
+	if err != nil {
+		if errors.Is(err, errXattrUnsupported) {
+			return nil
+		}
+		return fmt.Errorf("failed to read extended attributes of %s: %w", path, err)
+	}
+	if len(xattrs) == 0 {
+		return nil
+	}
+
+	if header.PAXRecords == nil {
+		header.PAXRecords = make(map[string]string, len(xattrs))
+	}
+	for name, value := range xattrs {
+		header.PAXRecords[paxXattrPrefix+name] = value
+	}
+	// PAX records are required to carry xattrs
+	header.Format = tar.FormatPAX
+
+	return nil
+}
+
+// applyXattrs sets the extended attributes recorded in the header on path
+// (without following symlinks). Attributes the destination filesystem cannot
+// store are reported as warnings; any other failure is an error.
+func applyXattrs(header *tar.Header, path string) error {
+	// Apply in a stable order so failures are reproducible
+	var names []string
+	for key := range header.PAXRecords {
+		if name, ok := strings.CutPrefix(key, paxXattrPrefix); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := header.PAXRecords[paxXattrPrefix+name]
+		if err := writeXattr(path, name, value); err != nil {
+			if errors.Is(err, errXattrUnsupported) {
+				fmt.Fprintf(os.Stderr, "Warning: cannot restore extended attribute %s on %s: %v\n", name, path, err)
+				continue
+			}
+			return fmt.Errorf("failed to set extended attribute %s on %s: %w", name, path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXattrs returns all extended attributes of path without following symlinks
+func readXattrs(path string) (map[string]string, error) {
+	names, err := listXattrNames(path)
+	if err != nil {
+		return nil, err
+	}
+
+	xattrs := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := getXattr(path, name)
+		if err != nil {
+			// Attribute removed between list and get
+			if errors.Is(err, unix.ENODATA) {
+				continue
+			}
+			return nil, err
+		}
+		xattrs[name] = value
+	}
+
+	return xattrs, nil
+}
+
+// listXattrNames returns the names of all extended attributes of path
+func listXattrNames(path string) ([]string, error) {
+	for {
+		size, err := unix.Llistxattr(path, nil)
+		if err != nil {
+			return nil, xattrError(err)
+		}
+		if size == 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size)
+		n, err := unix.Llistxattr(path, buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue // List grew between calls; retry
+		}
+		if err != nil {
+			return nil, xattrError(err)
+		}
+
+		var names []string
+		for _, name := range strings.Split(string(buf[:n]), "\x00") {
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+		return names, nil
+	}
+}
+
+// getXattr returns the value of a single extended attribute of path
+func getXattr(path, name string) (string, error) {
+	for {
+		size, err := unix.Lgetxattr(path, name, nil)
+		if err != nil {
+			return "", xattrError(err)
+		}
+
+		buf := make([]byte, size)
+		n, err := unix.Lgetxattr(path, name, buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue // Value grew between calls; retry
+		}
+		if err != nil {
+			return "", xattrError(err)
+		}
+		return string(buf[:n]), nil
+	}
+}
+
+// writeXattr sets a single extended attribute on path without following symlinks
+func writeXattr(path, name, value string) error {
+	if err := unix.Lsetxattr(path, name, []byte(value), 0); err != nil {
+		return xattrError(err)
+	}
+	return nil
+}
+
+// xattrError maps "not supported" errnos to errXattrUnsupported
+func xattrError(err error) error {
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		return fmt.Errorf("%w: %v", errXattrUnsupported, err)
+	}
+	return err
+}
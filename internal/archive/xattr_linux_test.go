@@ -0,0 +1,104 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// setTestXattr sets a user xattr or skips the test if the filesystem does not support them
+func setTestXattr(t *testing.T, path, name, value string) {
+	t.Helper()
+	if err := unix.Lsetxattr(path, name, []byte(value), 0); err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			t.Skip("Filesystem does not support user extended attributes")
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestReadXattrs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+	setTestXattr(t, path, "user.comment", "hello")
+	setTestXattr(t, path, "user.binary", "a\x00b")
+
+	got, err := readXattrs(path)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", got["user.comment"])
+	assert.Equal(t, "a\x00b", got["user.binary"])
+}
+
+func TestCreateTar_Xattrs(t *testing.T) {
+	srcDir := t.TempDir()
+	filePath := filepath.Join(srcDir, "file.txt")
+	require.NoError(t, os.WriteFile(filePath, []byte("data"), 0644))
+	setTestXattr(t, filePath, "user.comment", "hello")
+	setTestXattr(t, srcDir, "user.dir", "tagged")
+
+	var buf bytes.Buffer
+	_, err := CreateTar(srcDir, &buf, CreateConfig{})
+	require.NoError(t, err)
+	data := buf.Bytes()
+
+	// Verify xattrs are stored as SCHILY.xattr PAX records
+	records := make(map[string]map[string]string)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		records[header.Name] = header.PAXRecords
+	}
+	base := filepath.Base(srcDir)
+	assert.Equal(t, "hello", records[filepath.Join(base, "file.txt")]["SCHILY.xattr.user.comment"])
+	assert.Equal(t, "tagged", records[base]["SCHILY.xattr.user.dir"])
+
+	t.Run("restored with xattrs", func(t *testing.T) {
+		destDir := t.TempDir()
+		require.NoError(t, ExtractTar(bytes.NewReader(data), destDir, ExtractConfig{Xattrs: true}))
+
+		got, err := readXattrs(filepath.Join(destDir, base, "file.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello", got["user.comment"])
+
+		got, err = readXattrs(filepath.Join(destDir, base))
+		require.NoError(t, err)
+		assert.Equal(t, "tagged", got["user.dir"])
+	})
+
+	t.Run("not restored by default", func(t *testing.T) {
+		destDir := t.TempDir()
+		require.NoError(t, ExtractTar(bytes.NewReader(data), destDir, ExtractConfig{}))
+
+		got, err := readXattrs(filepath.Join(destDir, base, "file.txt"))
+		require.NoError(t, err)
+		assert.NotContains(t, got, "user.comment")
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// previousSuffix is appended to the destination to keep its old contents
+const previousSuffix = ".prev"
+
+// PreviousPath returns where an atomic restore keeps the old contents of dest
+func PreviousPath(dest string) string {
+	return filepath.Clean(dest) + previousSuffix
+}
+
+// checkAtomicDest rejects a dest that commitStaging could not replace. Renames
+// act on a symlink itself rather than the directory it points to, so dest must
+// be a real directory, or not exist yet.
+func checkAtomicDest(dest string) error {
+	info, err := os.Lstat(dest)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("destination %s is a symlink", dest)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("destination %s is not a directory", dest)
+	}
+	return nil
+}
+
+// createStagingDir creates an empty hidden sibling of dest, on the same file
+// system so it can be renamed into place. It takes the permissions of an
+// existing dest, or 0755.
+func createStagingDir(dest string) (string, error) {
+	absDest, err := filepath.Abs(dest)
+	if err != nil {
+		return "", err
+	}
+	parent := filepath.Dir(absDest)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", fmt.Errorf("failed to create parent directory %s: %w", parent, err)
+	}
+
+	staging, err := os.MkdirTemp(parent, "."+filepath.Base(absDest)+".staging-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	perm := os.FileMode(0755)
+	if info, err := os.Stat(absDest); err == nil && info.IsDir() {
+		perm = info.Mode().Perm()
+		copyOwner(info, staging)
+	}
+	if err := os.Chmod(staging, perm); err != nil {
+		os.Remove(staging)
+		return "", fmt.Errorf("failed to set staging directory permissions: %w", err)
+	}
+	return staging, nil
+}
+
+// commitStaging moves a fully extracted staging directory to dest. The old
+// contents of dest are moved to PreviousPath(dest) with keepPrevious and
+// removed otherwise. committed reports whether dest now holds the restored
+// contents; when it is false, staging is unchanged.
+//
+// Where supported, dest and staging are exchanged in one atomic rename.
+// Elsewhere dest is briefly absent between two renames, but never partial.
+func commitStaging(staging, dest string, keepPrevious bool) (committed bool, err error) {
+	info, err := os.Lstat(dest)
+	if os.IsNotExist(err) {
+		if err := os.Rename(staging, dest); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !info.IsDir() {
+		return false, fmt.Errorf("destination %s is not a directory", dest)
+	}
+
+	// After the swap, old holds the previous contents of dest
+	old := staging
+	if err := exchangeDirs(staging, dest); err != nil {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return false, err
+		}
+		old = staging + ".old"
+		if err := os.Rename(dest, old); err != nil {
+			return false, err
+		}
+		if err := os.Rename(staging, dest); err != nil {
+			os.Rename(old, dest) // Put the previous contents back
+			return false, err
+		}
+	}
+
+	if keepPrevious {
+		prev := PreviousPath(dest)
+		if err := os.RemoveAll(prev); err != nil {
+			return true, fmt.Errorf("failed to replace %s (previous contents are in %s): %w", prev, old, err)
+		}
+		if err := os.Rename(old, prev); err != nil {
+			return true, fmt.Errorf("failed to keep previous contents in %s (they are in %s): %w", prev, old, err)
+		}
+		return true, nil
+	}
+	if err := os.RemoveAll(old); err != nil {
+		return true, fmt.Errorf("failed to remove previous contents in %s: %w", old, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// populate creates dir containing a single file with the given content
+func populate(t *testing.T, dir, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), []byte(content), 0644))
+}
+
+func assertContent(t *testing.T, dir, want string) {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, "file"))
+	require.NoError(t, err)
+	assert.Equal(t, want, string(content))
+}
+
+func TestCheckAtomicDest(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "dir")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	file := filepath.Join(root, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	link := filepath.Join(root, "link")
+	require.NoError(t, os.Symlink(dir, link))
+
+	assert.NoError(t, checkAtomicDest(dir))
+	assert.NoError(t, checkAtomicDest(filepath.Join(root, "missing")))
+	assert.ErrorContains(t, checkAtomicDest(link), "is a symlink")
+	assert.ErrorContains(t, checkAtomicDest(file), "is not a directory")
+}
+
+func TestCreateStagingDir(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "www")
+	require.NoError(t, os.Mkdir(dest, 0750))
+
+	staging, err := createStagingDir(dest)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Dir(dest), filepath.Dir(staging), "staging must be a sibling of dest")
+
+	info, err := os.Stat(staging)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+}
+
+func TestCommitStaging(t *testing.T) {
+	t.Run("new destination", func(t *testing.T) {
+		root := t.TempDir()
+		staging, dest := filepath.Join(root, "staging"), filepath.Join(root, "dest")
+		populate(t, staging, "restored")
+
+		committed, err := commitStaging(staging, dest, false)
+		require.NoError(t, err)
+		assert.True(t, committed)
+		assertContent(t, dest, "restored")
+		assert.NoDirExists(t, staging)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		root := t.TempDir()
+		staging, dest := filepath.Join(root, "staging"), filepath.Join(root, "dest")
+		populate(t, staging, "restored")
+		populate(t, dest, "old")
+
+		committed, err := commitStaging(staging, dest, false)
+		require.NoError(t, err)
+		assert.True(t, committed)
+		assertContent(t, dest, "restored")
+
+		entries, err := os.ReadDir(root)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "previous contents are removed")
+	})
+
+	t.Run("keep previous", func(t *testing.T) {
+		root := t.TempDir()
+		staging, dest := filepath.Join(root, "staging"), filepath.Join(root, "dest")
+		populate(t, staging, "restored")
+		populate(t, dest, "old")
+		populate(t, PreviousPath(dest), "older") // Replaced by the new previous contents
+
+		committed, err := commitStaging(staging, dest, true)
+		require.NoError(t, err)
+		assert.True(t, committed)
+		assertContent(t, dest, "restored")
+		assertContent(t, PreviousPath(dest), "old")
+		assert.NoDirExists(t, staging)
+	})
+
+	t.Run("destination is a file", func(t *testing.T) {
+		root := t.TempDir()
+		staging, dest := filepath.Join(root, "staging"), filepath.Join(root, "dest")
+		populate(t, staging, "restored")
+		require.NoError(t, os.WriteFile(dest, nil, 0644))
+
+		committed, err := commitStaging(staging, dest, false)
+		require.Error(t, err)
+		assert.False(t, committed)
+		assertContent(t, staging, "restored")
+	})
+}
+
+func TestPreviousPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("var", "www.prev"), PreviousPath(filepath.Join("var", "www")+string(filepath.Separator)))
+}
@@ -19,10 +19,13 @@ package backup
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/icemarkom/secure-backup/internal/archive"
@@ -40,52 +43,82 @@ const pipeBufferSize = common.IOBufferSize
 
 // Config holds configuration for backup operations
 type Config struct {
-	SourcePath string
-	DestDir    string
-	Encryptor  encrypt.Encryptor
-	Compressor compress.Compressor
-	Verbose    bool
-	DryRun     bool
-	FileMode   *os.FileMode // nil = use system umask (os.Create); non-nil = explicit permissions
+	SourcePath       string   // Single source directory (used when SourcePaths is empty)
+	SourcePaths      []string // Source directories, each archived under its own top-level prefix
+	DestDir          string
+	Encryptor        encrypt.Encryptor
+	Compressor       compress.Compressor
+	CompressionLevel int // Effective level of Compressor, for display and the manifest
+	Verbose          bool
+	DryRun           bool
+	FileMode         *os.FileMode              // nil = use system umask (os.Create); non-nil = explicit permissions
+	Filter           archive.Filter            // Include/exclude patterns applied while archiving
+	IgnoreFile       string                    // Per-directory ignore file name (empty = disabled)
+	ExcludeCaches    bool                      // Skip contents of directories tagged with CACHEDIR.TAG
+	SpecialFiles     archive.SpecialFilePolicy // Handling of devices, FIFOs and sockets
+	OneFileSystem    bool                      // Do not cross mount points below the sources
+	FailOnChange     bool                      // Fail if a file changes while it is being archived
+	ContinueOnError  bool                      // Skip unreadable files (listed in Result.Errors) instead of failing
+	Reproducible     bool                      // Byte-identical tar streams for identical trees; sets Result.TarChecksum
+	ClampMtime       time.Time                 // Reproducible: clamp later modification times to this (zero = keep)
+	ReadAhead        int64                     // Memory budget for reading small files concurrently (0 = sequential)
+}
+
+// Sources returns the source paths of the backup
+func (c Config) Sources() []string {
+	if len(c.SourcePaths) > 0 {
+		return c.SourcePaths
+	}
+	return []string{c.SourcePath}
+}
+
+// Result summarizes a completed backup
+type Result struct {
+	UncompressedSize int64               // Raw file data bytes archived (excludes tar headers)
+	SkippedMounts    []string            // Mount points skipped in one-file-system mode
+	ChangedFiles     []string            // Files that changed while being archived (possibly inconsistent)
+	VanishedFiles    []string            // Files removed between the source scan and archiving
+	Errors           []archive.FileError // Unreadable files left out of the backup (ContinueOnError)
+	TarChecksum      string              // SHA256 of the uncompressed tar stream (Reproducible only)
+}
+
+// Partial reports whether files were left out of the backup because they
+// could not be read
+func (r Result) Partial() bool {
+	return len(r.Errors) > 0
 }
 
 // PerformBackup executes the backup pipeline: TAR → COMPRESS → ENCRYPT
-// Returns (outputPath, uncompressedSizeBytes, error)
-func PerformBackup(ctx context.Context, cfg Config) (string, int64, error) {
+// Returns (outputPath, result, error)
+func PerformBackup(ctx context.Context, cfg Config) (string, Result, error) {
 	// Handle dry-run mode
 	if cfg.DryRun {
 		return dryRunBackup(cfg)
 	}
 
-	// Validate source
-	_, err := os.Stat(cfg.SourcePath)
+	// Validate sources
+	prefixes, err := validateSources(cfg.Sources())
 	if err != nil {
-		if os.IsNotExist(err) {
-			return "", 0, common.MissingFile(cfg.SourcePath,
-				"Check that the path exists and you have permission to read it")
-		}
-		return "", 0, common.Wrap(err, fmt.Sprintf("Cannot access source: %s", cfg.SourcePath),
-			"Verify the path and check file permissions")
+		return "", Result{}, err
+	}
+
+	// List everything to archive once; sizes and the tar writer share the list
+	scan, err := scanSources(cfg)
+	if err != nil {
+		return "", Result{}, err
 	}
 
 	// Ensure destination directory exists
 	if err := os.MkdirAll(cfg.DestDir, 0755); err != nil {
-		return "", 0, fmt.Errorf("failed to create destination directory: %w", err)
+		return "", Result{}, fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
 	// Generate backup filename
-	timestamp := time.Now().Format("20060102_150405")
-	sourceName := filepath.Base(cfg.SourcePath)
-	filename := fmt.Sprintf("backup_%s_%s.tar%s.%s",
-		sourceName,
-		timestamp,
-		cfg.Compressor.Extension(),
-		cfg.Encryptor.Type())
-	outputPath := filepath.Join(cfg.DestDir, filename)
+	outputPath := filepath.Join(cfg.DestDir, backupFilename(prefixes, cfg))
 	tmpPath := outputPath + ".tmp"
 
 	if cfg.Verbose {
-		fmt.Printf("Starting backup of %s (%s)\n", cfg.SourcePath, common.Size(getDirectorySize(cfg.SourcePath)))
+		fmt.Printf("Starting backup of %s (%s)\n", strings.Join(cfg.Sources(), ", "), common.Size(scan.Size()))
 		fmt.Printf("Destination: %s\n", outputPath)
 	}
 
@@ -97,7 +130,7 @@ func PerformBackup(ctx context.Context, cfg Config) (string, int64, error) {
 		outFile, err = os.Create(tmpPath)
 	}
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to create output file: %w", err)
+		return "", Result{}, fmt.Errorf("failed to create output file: %w", err)
 	}
 	defer func() {
 		outFile.Close()
@@ -108,56 +141,82 @@ func PerformBackup(ctx context.Context, cfg Config) (string, int64, error) {
 	}()
 
 	// Execute the pipeline: TAR → COMPRESS → ENCRYPT → FILE
-	uncompressedSize, err := executePipeline(ctx, cfg, outFile)
+	result, err := executePipeline(ctx, cfg, scan, outFile)
 	if err != nil {
-		return "", 0, fmt.Errorf("backup pipeline failed: %w", err)
+		return "", Result{}, fmt.Errorf("backup pipeline failed: %w", err)
 	}
 
 	// Close file before rename (required on some platforms)
 	if err = outFile.Close(); err != nil {
-		return "", 0, fmt.Errorf("failed to close backup file: %w", err)
+		return "", Result{}, fmt.Errorf("failed to close backup file: %w", err)
 	}
 
 	// Atomic rename to final path
 	if err = os.Rename(tmpPath, outputPath); err != nil {
 		os.Remove(tmpPath) // Clean up temp file
-		return "", 0, fmt.Errorf("failed to finalize backup file: %w", err)
+		return "", Result{}, fmt.Errorf("failed to finalize backup file: %w", err)
 	}
 
 	// Get final file size
 	finalInfo, _ := os.Stat(outputPath)
 
+	if cfg.Verbose && len(result.ChangedFiles) > 0 {
+		fmt.Printf("WARNING: %d files changed while being archived and may be inconsistent:\n", len(result.ChangedFiles))
+		for _, file := range result.ChangedFiles {
+			fmt.Printf("  %s\n", file)
+		}
+	}
+
+	if cfg.Verbose && len(result.VanishedFiles) > 0 {
+		fmt.Printf("Note: %d files were removed before they could be archived:\n", len(result.VanishedFiles))
+		for _, file := range result.VanishedFiles {
+			fmt.Printf("  %s\n", file)
+		}
+	}
+
 	if cfg.Verbose {
 		fmt.Printf("Backup completed successfully: %s\n", outputPath)
 		if finalInfo != nil {
 			fmt.Printf("Backup size: %s\n", common.Size(finalInfo.Size()))
 		}
+		if result.TarChecksum != "" {
+			fmt.Printf("Archive SHA256: %s\n", result.TarChecksum)
+		}
 	}
 
-	return outputPath, uncompressedSize, nil
+	return outputPath, result, nil
 }
 
 // executePipeline runs the backup pipeline with comprehensive error propagation.
-// Returns the uncompressed size (raw file data bytes from CreateTar).
-func executePipeline(ctx context.Context, cfg Config, output io.Writer) (int64, error) {
+// The tar stage writes the entries of scan. Returns the uncompressed size (raw
+// file data bytes from WriteTar) and the entries skipped or changed while archiving.
+func executePipeline(ctx context.Context, cfg Config, scan *archive.Scan, output io.Writer) (Result, error) {
 	// Use provided context for pipeline coordination
 	g, ctx := errgroup.WithContext(ctx)
 
 	// Step 1: Create tar reader pipe
 	tarPR, tarPW := io.Pipe()
 
-	// Capture uncompressed size from tar goroutine
-	var uncompressedSize int64
+	// Capture archive results from tar goroutine
+	var tarResult archive.Result
+
+	// Reproducible archives are fingerprinted before compression and encryption,
+	// which are not deterministic
+	var tarOut io.Writer = tarPW
+	tarHash := sha256.New()
+	if cfg.Reproducible {
+		tarOut = io.MultiWriter(tarPW, tarHash)
+	}
 
 	// Goroutine 1: Create TAR archive
 	g.Go(func() error {
 		defer tarPW.Close()
-		n, err := archive.CreateTar(cfg.SourcePath, tarPW)
+		res, err := archive.WriteTar(tarOut, scan)
 		if err != nil {
 			tarPW.CloseWithError(err)
 			return fmt.Errorf("tar creation failed: %w", err)
 		}
-		uncompressedSize = n
+		tarResult = res
 		return nil
 	})
 
@@ -168,80 +227,159 @@ func executePipeline(ctx context.Context, cfg Config, output io.Writer) (int64,
 	// Wrap with progress tracking (measures source bytes read through tar)
 	pr := progress.NewReader(bufferedTarPR, progress.Config{
 		Description: "Backing up",
-		TotalBytes:  getDirectorySize(cfg.SourcePath),
+		TotalBytes:  scan.Size(),
 		Enabled:     cfg.Verbose,
 	})
 
 	compressPR, err := cfg.Compressor.Compress(pr)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create compressor: %w", err)
+		return Result{}, fmt.Errorf("failed to create compressor: %w", err)
 	}
 
 	// Step 3: Encrypt the compressed stream
 	// Note: Encryptor.Encrypt spawns its own goroutine internally
 	encryptPR, err := cfg.Encryptor.Encrypt(compressPR)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create encryptor: %w", err)
+		return Result{}, fmt.Errorf("failed to create encryptor: %w", err)
 	}
 
 	// Step 4: Write encrypted stream to output file
 	// This will capture errors from compress/encrypt goroutines via pipe errors
 	if _, err := io.CopyBuffer(output, encryptPR, common.NewBuffer()); err != nil {
-		return 0, fmt.Errorf("failed to write output: %w", err)
+		return Result{}, fmt.Errorf("failed to write output: %w", err)
 	}
 	pr.Finish()
 
 	// Wait for tar goroutine to complete
 	// Any errors from compress/encrypt will have already been caught by io.Copy above
 	if err := g.Wait(); err != nil {
-		return 0, err
+		return Result{}, err
 	}
 
-	return uncompressedSize, nil
+	result := Result{
+		UncompressedSize: tarResult.BytesWritten,
+		SkippedMounts:    tarResult.SkippedMounts,
+		ChangedFiles:     tarResult.ChangedFiles,
+		VanishedFiles:    tarResult.VanishedFiles,
+		Errors:           tarResult.Errors,
+	}
+	if cfg.Reproducible {
+		result.TarChecksum = hex.EncodeToString(tarHash.Sum(nil))
+	}
+	return result, nil
 }
 
-// getDirectorySize calculates the total size of a directory (best effort)
-func getDirectorySize(path string) int64 {
-	var size int64
-	filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
-		if err == nil && info != nil && !info.IsDir() {
-			size += info.Size()
+// validateSources checks that every source exists, that no source is inside
+// another and that their archive prefixes are unique. Returns the prefixes.
+func validateSources(sources []string) ([]string, error) {
+	for _, source := range sources {
+		_, err := os.Stat(source)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, common.MissingFile(source,
+					"Check that the path exists and you have permission to read it")
+			}
+			return nil, common.Wrap(err, fmt.Sprintf("Cannot access source: %s", source),
+				"Verify the path and check file permissions")
 		}
-		return nil
-	})
-	return size
-}
+	}
 
-// dryRunBackup previews backup operation without executing
-// Note: Dry-run mode always shows verbose output for useful preview
-func dryRunBackup(cfg Config) (string, int64, error) {
-	// Validate source exists
-	_, err := os.Stat(cfg.SourcePath)
+	prefixes, err := archive.SourcePrefixes(sources)
 	if err != nil {
-		return "", 0, fmt.Errorf("invalid source path: %w", err)
+		return nil, common.InvalidConfig("--source", err.Error(),
+			"Each source is stored under its directory name; sources must not contain one another, and sources with the same name must be backed up separately")
 	}
+	return prefixes, nil
+}
 
-	// Generate backup filename (same logic as real backup)
+// backupFilename returns the backup file name for the given source prefixes:
+// backup_<prefix>[+<prefix>...]_<timestamp>.tar<ext>.<enc>
+func backupFilename(prefixes []string, cfg Config) string {
 	timestamp := time.Now().Format("20060102_150405")
-	sourceName := filepath.Base(cfg.SourcePath)
-	filename := fmt.Sprintf("backup_%s_%s.tar%s.%s",
-		sourceName,
+	return fmt.Sprintf("backup_%s_%s.tar%s.%s",
+		strings.Join(prefixes, "+"),
 		timestamp,
 		cfg.Compressor.Extension(),
 		cfg.Encryptor.Type())
-	outputPath := filepath.Join(cfg.DestDir, filename)
+}
+
+// createConfig returns the archive settings of the backup
+func (c Config) createConfig() archive.CreateConfig {
+	return archive.CreateConfig{
+		Filter:          c.Filter,
+		IgnoreFile:      c.IgnoreFile,
+		ExcludeCaches:   c.ExcludeCaches,
+		SpecialFiles:    c.SpecialFiles,
+		OneFileSystem:   c.OneFileSystem,
+		FailOnChange:    c.FailOnChange,
+		ContinueOnError: c.ContinueOnError,
+		Reproducible:    c.Reproducible,
+		ClampMtime:      c.ClampMtime,
+		ReadAhead:       c.ReadAhead,
+	}
+}
 
-	// Calculate source size
-	sourceSize := getDirectorySize(cfg.SourcePath)
+// scanSources lists the entries of all sources in a single walk
+func scanSources(cfg Config) (*archive.Scan, error) {
+	scan, err := archive.ScanSources(cfg.Sources(), cfg.createConfig())
+	if err != nil {
+		return nil, common.Wrap(err, fmt.Sprintf("Failed to scan backup sources: %v", err),
+			"Check source permissions, or use --continue-on-error to skip unreadable files")
+	}
+	return scan, nil
+}
+
+// dryRunBackup previews backup operation without executing
+// Note: Dry-run mode always shows verbose output for useful preview
+func dryRunBackup(cfg Config) (string, Result, error) {
+	// Validate sources exist
+	for _, source := range cfg.Sources() {
+		if _, err := os.Stat(source); err != nil {
+			return "", Result{}, fmt.Errorf("invalid source path: %w", err)
+		}
+	}
+	prefixes, err := archive.SourcePrefixes(cfg.Sources())
+	if err != nil {
+		return "", Result{}, fmt.Errorf("invalid source paths: %w", err)
+	}
+
+	// Generate backup filename (same logic as real backup)
+	outputPath := filepath.Join(cfg.DestDir, backupFilename(prefixes, cfg))
 
 	encType := cfg.Encryptor.Type()
 
+	// The scan applies filters, so sizes match what would be archived
+	scan, err := scanSources(cfg)
+	if err != nil {
+		return "", Result{}, err
+	}
+
 	// Print dry-run preview (always verbose)
 	fmt.Println("[DRY RUN] Backup preview:")
-	fmt.Printf("[DRY RUN]   Source: %s (%s)\n", cfg.SourcePath, common.Size(sourceSize))
+	for _, source := range cfg.Sources() {
+		fmt.Printf("[DRY RUN]   Source: %s (%s)\n", source, common.Size(scan.Sizes[source]))
+	}
+	fmt.Printf("[DRY RUN]   Entries: %d (%s)\n", len(scan.Entries), common.Size(scan.Size()))
 	fmt.Printf("[DRY RUN]   Destination: %s\n", outputPath)
-	fmt.Printf("[DRY RUN]   Compression: %s\n", cfg.Compressor.Type())
+	if cfg.Compressor.Type() != compress.None {
+		fmt.Printf("[DRY RUN]   Compression: %s (level %d)\n", cfg.Compressor.Type(), cfg.CompressionLevel)
+	} else {
+		fmt.Printf("[DRY RUN]   Compression: %s\n", cfg.Compressor.Type())
+	}
 	fmt.Printf("[DRY RUN]   Encryption: %s\n", encType)
+	if len(cfg.Filter.Excludes) > 0 {
+		fmt.Printf("[DRY RUN]   Exclude patterns: %s\n", strings.Join(cfg.Filter.Excludes, ", "))
+	}
+	if len(cfg.Filter.Includes) > 0 {
+		fmt.Printf("[DRY RUN]   Include patterns: %s\n", strings.Join(cfg.Filter.Includes, ", "))
+	}
+	if cfg.Reproducible {
+		if cfg.ClampMtime.IsZero() {
+			fmt.Println("[DRY RUN]   Reproducible: yes")
+		} else {
+			fmt.Printf("[DRY RUN]   Reproducible: yes (modification times clamped to %s)\n", cfg.ClampMtime.UTC().Format(time.RFC3339))
+		}
+	}
 	fmt.Println("[DRY RUN]")
 	fmt.Println("[DRY RUN] Pipeline stages that would execute:")
 	fmt.Println("[DRY RUN]   - TAR - Archive source directory")
@@ -251,5 +389,5 @@ func dryRunBackup(cfg Config) (string, int64, error) {
 	fmt.Printf("[DRY RUN]   - ENCRYPT - Encrypt with %s\n", encType)
 	fmt.Println("[DRY RUN]   - WRITE - Write to destination file")
 
-	return outputPath, 0, nil
+	return outputPath, Result{}, nil
 }
@@ -24,13 +24,14 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/icemarkom/secure-backup/internal/archive"
 	"github.com/icemarkom/secure-backup/internal/compress"
 	"github.com/icemarkom/secure-backup/internal/encrypt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestGetDirectorySize(t *testing.T) {
+func TestScanSources_Size(t *testing.T) {
 	// Create temp directory with known files
 	tempDir := t.TempDir()
 
@@ -48,24 +49,37 @@ func TestGetDirectorySize(t *testing.T) {
 	err = os.WriteFile(file2, bytes.Repeat([]byte("b"), 500), 0644)
 	require.NoError(t, err)
 
-	// Get directory size
-	size := getDirectorySize(tempDir)
+	scan, err := scanSources(Config{SourcePath: tempDir})
+	require.NoError(t, err)
 
 	// Should be 1000 + 500 = 1500 bytes
-	assert.Equal(t, int64(1500), size)
+	assert.Equal(t, int64(1500), scan.Size())
+	assert.Equal(t, int64(1500), scan.Sizes[tempDir])
+	assert.Len(t, scan.Entries, 4)
+}
+
+func TestScanSources_SizeExcludesFiltered(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "keep.txt"), bytes.Repeat([]byte("a"), 1000), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "skip.tmp"), bytes.Repeat([]byte("b"), 500), 0644))
+
+	scan, err := scanSources(Config{SourcePath: tempDir, Filter: archive.Filter{Excludes: []string{"*.tmp"}}})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1000), scan.Size())
 }
 
-func TestGetDirectorySize_NonexistentDirectory(t *testing.T) {
-	// Should return 0 for nonexistent directory (walks nothing)
-	size := getDirectorySize("/nonexistent/directory/path")
-	assert.Equal(t, int64(0), size)
+func TestScanSources_NonexistentDirectory(t *testing.T) {
+	_, err := scanSources(Config{SourcePath: "/nonexistent/directory/path"})
+	assert.Error(t, err)
 }
 
-func TestGetDirectorySize_EmptyDirectory(t *testing.T) {
+func TestScanSources_EmptyDirectory(t *testing.T) {
 	tempDir := t.TempDir()
 
-	size := getDirectorySize(tempDir)
-	assert.Equal(t, int64(0), size)
+	scan, err := scanSources(Config{SourcePath: tempDir})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), scan.Size())
 }
 
 // TestPerformBackup_InvalidSource tests error handling for invalid source paths
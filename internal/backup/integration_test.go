@@ -19,11 +19,16 @@ package backup
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/icemarkom/secure-backup/internal/archive"
 	"github.com/icemarkom/secure-backup/internal/compress"
 	"github.com/icemarkom/secure-backup/internal/encrypt"
 	"github.com/stretchr/testify/assert"
@@ -94,19 +99,19 @@ func TestIntegration_BackupRestoreCycle(t *testing.T) {
 		Verbose:    false,
 	}
 
-	backupPath, uncompressedSize, err := PerformBackup(context.Background(), backupCfg)
+	backupPath, result, err := PerformBackup(context.Background(), backupCfg)
 	require.NoError(t, err)
 	assert.FileExists(t, backupPath, "backup file should exist")
 
 	// Verify uncompressed size is positive and matches expected raw data bytes
-	assert.Greater(t, uncompressedSize, int64(0), "uncompressed size should be positive")
+	assert.Greater(t, result.UncompressedSize, int64(0), "uncompressed size should be positive")
 
 	// Calculate expected raw data size from test files
 	var expectedDataSize int64
 	for _, content := range testFiles {
 		expectedDataSize += int64(len(content))
 	}
-	assert.Equal(t, expectedDataSize, uncompressedSize, "uncompressed size should equal sum of file content bytes")
+	assert.Equal(t, expectedDataSize, result.UncompressedSize, "uncompressed size should equal sum of file content bytes")
 
 	// Verify backup file is not empty and compressed size < uncompressed (text data compresses well)
 	info, err := os.Stat(backupPath)
@@ -572,3 +577,458 @@ func TestIntegration_AGE_BackupVerifyCycle(t *testing.T) {
 		assert.NoError(t, err, "full verification should pass")
 	})
 }
+
+// TestIntegration_MultipleSources tests that several sources round-trip through one backup
+func TestIntegration_MultipleSources(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	tempRoot := t.TempDir()
+
+	// Sources live at different depths; each is stored under its base name
+	sources := map[string]string{
+		filepath.Join(tempRoot, "etc"):               "etc/config.txt",
+		filepath.Join(tempRoot, "home"):              "home/user.txt",
+		filepath.Join(tempRoot, "var", "lib", "app"): "app/state.db",
+	}
+	var sourcePaths []string
+	for source, rel := range sources {
+		require.NoError(t, os.MkdirAll(source, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(source, filepath.Base(rel)), []byte(rel), 0644))
+		sourcePaths = append(sourcePaths, source)
+	}
+
+	backupDir := filepath.Join(tempRoot, "backups")
+	restoreDir := filepath.Join(tempRoot, "restore")
+	ageKeys := generateTestAgeKeys(t, tempRoot)
+
+	compressor, err := compress.NewCompressor(compress.Config{Method: compress.Gzip})
+	require.NoError(t, err)
+
+	encryptor, err := encrypt.NewEncryptor(encrypt.Config{
+		Method:     encrypt.AGE,
+		PublicKey:  ageKeys.Recipient,
+		PrivateKey: ageKeys.IdentityFile,
+	})
+	require.NoError(t, err)
+
+	backupPath, _, err := PerformBackup(context.Background(), Config{
+		SourcePaths: sourcePaths,
+		DestDir:     backupDir,
+		Encryptor:   encryptor,
+		Compressor:  compressor,
+	})
+	require.NoError(t, err)
+
+	for _, prefix := range []string{"etc", "home", "app"} {
+		assert.Contains(t, filepath.Base(backupPath), prefix)
+	}
+
+	err = PerformRestore(context.Background(), RestoreConfig{
+		BackupFile: backupPath,
+		DestPath:   restoreDir,
+		Encryptor:  encryptor,
+		Compressor: compressor,
+	})
+	require.NoError(t, err)
+
+	for _, rel := range sources {
+		content, err := os.ReadFile(filepath.Join(restoreDir, rel))
+		require.NoError(t, err, "restored file %s should exist", rel)
+		assert.Equal(t, rel, string(content))
+	}
+}
+
+// TestPerformBackup_DuplicateSourceNames tests that sources sharing a base name are rejected
+func TestPerformBackup_DuplicateSourceNames(t *testing.T) {
+	tempRoot := t.TempDir()
+	first := filepath.Join(tempRoot, "etc")
+	second := filepath.Join(tempRoot, "usr", "local", "etc")
+	require.NoError(t, os.MkdirAll(first, 0755))
+	require.NoError(t, os.MkdirAll(second, 0755))
+
+	ageKeys := generateTestAgeKeys(t, tempRoot)
+	compressor, err := compress.NewCompressor(compress.Config{Method: compress.Gzip})
+	require.NoError(t, err)
+	encryptor, err := encrypt.NewEncryptor(encrypt.Config{Method: encrypt.AGE, PublicKey: ageKeys.Recipient})
+	require.NoError(t, err)
+
+	_, _, err = PerformBackup(context.Background(), Config{
+		SourcePaths: []string{first, second},
+		DestDir:     filepath.Join(tempRoot, "backups"),
+		Encryptor:   encryptor,
+		Compressor:  compressor,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--source")
+}
+
+// TestIntegration_SelectiveRestore tests restoring only part of a backup
+func TestIntegration_SelectiveRestore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	tempRoot := t.TempDir()
+	sourceDir := filepath.Join(tempRoot, "data")
+	for _, rel := range []string{"docs/report.pdf", "docs/notes.txt", "photos/a.jpg"} {
+		path := filepath.Join(sourceDir, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(rel), 0644))
+	}
+
+	ageKeys := generateTestAgeKeys(t, tempRoot)
+	compressor, err := compress.NewCompressor(compress.Config{Method: compress.Gzip})
+	require.NoError(t, err)
+	encryptor, err := encrypt.NewEncryptor(encrypt.Config{
+		Method:     encrypt.AGE,
+		PublicKey:  ageKeys.Recipient,
+		PrivateKey: ageKeys.IdentityFile,
+	})
+	require.NoError(t, err)
+
+	backupPath, _, err := PerformBackup(context.Background(), Config{
+		SourcePath: sourceDir,
+		DestDir:    filepath.Join(tempRoot, "backups"),
+		Encryptor:  encryptor,
+		Compressor: compressor,
+	})
+	require.NoError(t, err)
+
+	t.Run("path and exclude", func(t *testing.T) {
+		restoreDir := filepath.Join(tempRoot, "restore")
+		err := PerformRestore(context.Background(), RestoreConfig{
+			BackupFile: backupPath,
+			DestPath:   restoreDir,
+			Encryptor:  encryptor,
+			Compressor: compressor,
+			Paths:      []string{"data/docs"},
+			Filter:     archive.Filter{Excludes: []string{"*.txt"}},
+		})
+		require.NoError(t, err)
+
+		assert.FileExists(t, filepath.Join(restoreDir, "data", "docs", "report.pdf"))
+		assert.NoFileExists(t, filepath.Join(restoreDir, "data", "docs", "notes.txt"))
+		assert.NoDirExists(t, filepath.Join(restoreDir, "data", "photos"))
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		err := PerformRestore(context.Background(), RestoreConfig{
+			BackupFile: backupPath,
+			DestPath:   filepath.Join(tempRoot, "empty"),
+			Encryptor:  encryptor,
+			Compressor: compressor,
+			Paths:      []string{"data/missing"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "No archive entries matched")
+	})
+}
+
+// TestIntegration_MirrorRestore tests rolling a directory back to the backup's exact state
+func TestIntegration_MirrorRestore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	tempRoot := t.TempDir()
+	sourceDir := filepath.Join(tempRoot, "www")
+	require.NoError(t, os.MkdirAll(sourceDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "index.html"), []byte("original"), 0644))
+
+	ageKeys := generateTestAgeKeys(t, tempRoot)
+	compressor, err := compress.NewCompressor(compress.Config{Method: compress.Gzip})
+	require.NoError(t, err)
+	encryptor, err := encrypt.NewEncryptor(encrypt.Config{
+		Method:     encrypt.AGE,
+		PublicKey:  ageKeys.Recipient,
+		PrivateKey: ageKeys.IdentityFile,
+	})
+	require.NoError(t, err)
+
+	backupPath, _, err := PerformBackup(context.Background(), Config{
+		SourcePath: sourceDir,
+		DestDir:    filepath.Join(tempRoot, "backups"),
+		Encryptor:  encryptor,
+		Compressor: compressor,
+	})
+	require.NoError(t, err)
+
+	// Compromise the restored tree: modify a file and plant new ones
+	restoreDir := filepath.Join(tempRoot, "restore")
+	index := filepath.Join(restoreDir, "www", "index.html")
+	shell := filepath.Join(restoreDir, "www", "shell.php")
+	require.NoError(t, os.MkdirAll(filepath.Join(restoreDir, "www", "uploads"), 0755))
+	require.NoError(t, os.WriteFile(index, []byte("defaced"), 0644))
+	require.NoError(t, os.WriteFile(shell, []byte("<?php"), 0644))
+
+	mirrorCfg := RestoreConfig{
+		BackupFile: backupPath,
+		DestPath:   restoreDir,
+		Encryptor:  encryptor,
+		Compressor: compressor,
+		Mirror:     true,
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		cfg := mirrorCfg
+		cfg.DryRun = true
+		require.NoError(t, PerformRestore(context.Background(), cfg))
+		assert.FileExists(t, shell)
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		var asked []string
+		cfg := mirrorCfg
+		cfg.Confirm = func(paths []string) (bool, error) {
+			asked = paths
+			return false, nil
+		}
+		err := PerformRestore(context.Background(), cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cancelled")
+		assert.ElementsMatch(t, []string{shell, filepath.Join(restoreDir, "www", "uploads")}, asked)
+
+		content, err := os.ReadFile(index)
+		require.NoError(t, err)
+		assert.Equal(t, "defaced", string(content), "nothing is restored when declined")
+		assert.FileExists(t, shell)
+	})
+
+	t.Run("confirmed", func(t *testing.T) {
+		cfg := mirrorCfg
+		cfg.Confirm = func(paths []string) (bool, error) { return true, nil }
+		require.NoError(t, PerformRestore(context.Background(), cfg))
+
+		content, err := os.ReadFile(index)
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(content))
+		assert.NoFileExists(t, shell)
+		assert.NoDirExists(t, filepath.Join(restoreDir, "www", "uploads"))
+	})
+}
+
+// TestIntegration_AtomicRestore tests that atomic restores replace the destination only on success
+func TestIntegration_AtomicRestore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	tempRoot := t.TempDir()
+	sourceDir := filepath.Join(tempRoot, "www")
+	require.NoError(t, os.MkdirAll(sourceDir, 0755))
+	for _, name := range []string{"a.bin", "b.bin"} {
+		data := make([]byte, 256*1024)
+		_, err := rand.Read(data)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(sourceDir, name), data, 0644))
+	}
+
+	ageKeys := generateTestAgeKeys(t, tempRoot)
+	compressor, err := compress.NewCompressor(compress.Config{Method: compress.None})
+	require.NoError(t, err)
+	encryptor, err := encrypt.NewEncryptor(encrypt.Config{
+		Method:     encrypt.AGE,
+		PublicKey:  ageKeys.Recipient,
+		PrivateKey: ageKeys.IdentityFile,
+	})
+	require.NoError(t, err)
+
+	backupPath, _, err := PerformBackup(context.Background(), Config{
+		SourcePath: sourceDir,
+		DestDir:    filepath.Join(tempRoot, "backups"),
+		Encryptor:  encryptor,
+		Compressor: compressor,
+	})
+	require.NoError(t, err)
+
+	// The live destination
+	restoreDir := filepath.Join(tempRoot, "live")
+	liveFile := filepath.Join(restoreDir, "current.txt")
+	require.NoError(t, os.MkdirAll(restoreDir, 0755))
+	require.NoError(t, os.WriteFile(liveFile, []byte("live"), 0644))
+
+	atomicCfg := RestoreConfig{
+		BackupFile:   backupPath,
+		DestPath:     restoreDir,
+		Encryptor:    encryptor,
+		Compressor:   compressor,
+		Atomic:       true,
+		KeepPrevious: true,
+	}
+
+	t.Run("failure leaves destination unchanged", func(t *testing.T) {
+		// Truncate a copy mid-way through the second file
+		info, err := os.Stat(backupPath)
+		require.NoError(t, err)
+		truncated := filepath.Join(tempRoot, "truncated.tar.age")
+		data, err := os.ReadFile(backupPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(truncated, data[:info.Size()*3/4], 0600))
+
+		cfg := atomicCfg
+		cfg.BackupFile = truncated
+		err = PerformRestore(context.Background(), cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "destination was not changed")
+
+		entries, err := os.ReadDir(restoreDir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "current.txt", entries[0].Name())
+
+		// No staging directory is left behind
+		siblings, err := os.ReadDir(tempRoot)
+		require.NoError(t, err)
+		for _, s := range siblings {
+			assert.NotContains(t, s.Name(), "staging")
+		}
+	})
+
+	t.Run("symlink destination is rejected up front", func(t *testing.T) {
+		link := filepath.Join(tempRoot, "live-link")
+		require.NoError(t, os.Symlink(restoreDir, link))
+
+		cfg := atomicCfg
+		cfg.DestPath = link
+		err := PerformRestore(context.Background(), cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot replace the destination")
+
+		// Nothing was staged
+		siblings, err := os.ReadDir(tempRoot)
+		require.NoError(t, err)
+		for _, s := range siblings {
+			assert.NotContains(t, s.Name(), "staging")
+		}
+	})
+
+	t.Run("success swaps and keeps previous", func(t *testing.T) {
+		require.NoError(t, PerformRestore(context.Background(), atomicCfg))
+
+		assert.FileExists(t, filepath.Join(restoreDir, "www", "a.bin"))
+		assert.FileExists(t, filepath.Join(restoreDir, "www", "b.bin"))
+		assert.NoFileExists(t, liveFile)
+		assert.FileExists(t, filepath.Join(PreviousPath(restoreDir), "current.txt"))
+	})
+}
+
+// TestIntegration_ReproducibleBackup tests that backups of identical trees can be
+// compared by their archive checksum, and that verify checks it
+func TestIntegration_ReproducibleBackup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	tempRoot := t.TempDir()
+	mtime := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var sources []string
+	for _, copyName := range []string{"original", "copy"} {
+		sourceDir := filepath.Join(tempRoot, copyName, "data")
+		require.NoError(t, os.MkdirAll(sourceDir, 0755))
+		file := filepath.Join(sourceDir, "report.txt")
+		require.NoError(t, os.WriteFile(file, []byte("quarterly numbers"), 0644))
+		require.NoError(t, os.Chtimes(file, mtime, mtime))
+		require.NoError(t, os.Chtimes(sourceDir, mtime, mtime))
+		sources = append(sources, sourceDir)
+	}
+
+	ageKeys := generateTestAgeKeys(t, tempRoot)
+	compressor, err := compress.NewCompressor(compress.Config{Method: compress.Gzip})
+	require.NoError(t, err)
+	encryptor, err := encrypt.NewEncryptor(encrypt.Config{
+		Method:     encrypt.AGE,
+		PublicKey:  ageKeys.Recipient,
+		PrivateKey: ageKeys.IdentityFile,
+	})
+	require.NoError(t, err)
+
+	var backupPaths, checksums []string
+	for i, sourceDir := range sources {
+		backupPath, result, err := PerformBackup(context.Background(), Config{
+			SourcePath:   sourceDir,
+			DestDir:      filepath.Join(tempRoot, fmt.Sprintf("backups%d", i)),
+			Encryptor:    encryptor,
+			Compressor:   compressor,
+			Reproducible: true,
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, result.TarChecksum)
+		backupPaths = append(backupPaths, backupPath)
+		checksums = append(checksums, result.TarChecksum)
+	}
+
+	// Encrypted files differ, the archives inside do not
+	first, err := os.ReadFile(backupPaths[0])
+	require.NoError(t, err)
+	second, err := os.ReadFile(backupPaths[1])
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, checksums[0], checksums[1])
+
+	verifyCfg := VerifyConfig{
+		BackupFile:  backupPaths[1],
+		Encryptor:   encryptor,
+		Compressor:  compressor,
+		TarChecksum: checksums[0],
+	}
+	assert.NoError(t, PerformVerify(context.Background(), verifyCfg))
+
+	verifyCfg.TarChecksum = strings.Repeat("0", 64)
+	err = PerformVerify(context.Background(), verifyCfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Archive checksum mismatch")
+}
+
+// TestIntegration_CompressionTuning tests that backups made with compression
+// threads and a large zstd window verify with the default decompressor
+func TestIntegration_CompressionTuning(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	tempRoot := t.TempDir()
+	sourceDir := filepath.Join(tempRoot, "source")
+	require.NoError(t, os.Mkdir(sourceDir, 0755))
+	for i := range 4 {
+		content := strings.Repeat(fmt.Sprintf("record %d of a tuned backup\n", i), 50000)
+		require.NoError(t, os.WriteFile(filepath.Join(sourceDir, fmt.Sprintf("file%d.txt", i)), []byte(content), 0644))
+	}
+	ageKeys := generateTestAgeKeys(t, tempRoot)
+
+	encryptor, err := encrypt.NewEncryptor(encrypt.Config{
+		Method:     encrypt.AGE,
+		PublicKey:  ageKeys.Recipient,
+		PrivateKey: ageKeys.IdentityFile,
+	})
+	require.NoError(t, err)
+
+	for _, cfg := range []compress.Config{
+		{Method: compress.Gzip, Threads: 2},
+		{Method: compress.Zstd, Threads: 4, Window: compress.MaxZstdWindow},
+		{Method: compress.Lz4, Threads: 3},
+	} {
+		t.Run(cfg.Method.String(), func(t *testing.T) {
+			compressor, err := compress.NewCompressor(cfg)
+			require.NoError(t, err)
+			backupPath, _, err := PerformBackup(context.Background(), Config{
+				SourcePath: sourceDir,
+				DestDir:    filepath.Join(tempRoot, cfg.Method.String()),
+				Encryptor:  encryptor,
+				Compressor: compressor,
+			})
+			require.NoError(t, err)
+
+			// Restore and verify build their decompressor without tuning
+			plain, err := compress.NewCompressor(compress.Config{Method: cfg.Method})
+			require.NoError(t, err)
+			err = PerformVerify(context.Background(), VerifyConfig{
+				BackupFile: backupPath,
+				Encryptor:  encryptor,
+				Compressor: plain,
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -19,7 +19,10 @@ package backup
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/icemarkom/secure-backup/internal/archive"
 	"github.com/icemarkom/secure-backup/internal/common"
@@ -30,20 +33,45 @@ import (
 
 // RestoreConfig holds configuration for restore operations
 type RestoreConfig struct {
-	BackupFile string
-	DestPath   string
-	Encryptor  encrypt.Encryptor
-	Compressor compress.Compressor
-	Verbose    bool
-	DryRun     bool
-	Force      bool
+	BackupFile   string
+	DestPath     string
+	Encryptor    encrypt.Encryptor
+	Compressor   compress.Compressor
+	Verbose      bool
+	DryRun       bool
+	Force        bool
+	SameOwner    bool           // Restore archived file ownership (requires root)
+	NumericOwner bool           // Ignore user/group names, use archived uid/gid
+	UIDMap       map[int]int    // Remap archived UIDs
+	GIDMap       map[int]int    // Remap archived GIDs
+	Xattrs       bool           // Restore extended attributes
+	Devices      bool           // Recreate device nodes (requires root)
+	Paths        []string       // Only restore these archive paths (empty = everything)
+	Filter       archive.Filter // Include/exclude patterns applied to archive paths
+
+	StripComponents int                  // Leading path components removed from entry names
+	Relocate        []archive.Relocation // Archive subtrees restored under different paths
+
+	OnConflict archive.ConflictPolicy // Handling of files that already exist in the destination
+
+	// Mirror removes files and directories under DestPath that are not in the
+	// backup. When Confirm is set, it is called with the paths to remove before
+	// anything is written; returning false cancels the restore.
+	Mirror  bool
+	Confirm func(paths []string) (bool, error)
+
+	// Atomic extracts into a staging directory next to DestPath and renames it
+	// into place only after the whole backup was read without errors.
+	// KeepPrevious keeps the old contents as PreviousPath(DestPath).
+	Atomic       bool
+	KeepPrevious bool
 }
 
 // PerformRestore executes the restore pipeline: DECRYPT → DECOMPRESS → EXTRACT
 func PerformRestore(ctx context.Context, cfg RestoreConfig) error {
 	// Handle dry-run mode
 	if cfg.DryRun {
-		return dryRunRestore(cfg)
+		return dryRunRestore(ctx, cfg)
 	}
 
 	// Validate backup file exists
@@ -56,6 +84,14 @@ func PerformRestore(ctx context.Context, cfg RestoreConfig) error {
 			"Check file permissions")
 	}
 
+	// Atomic restores replace dest by renaming, which must work before anything is extracted
+	if cfg.Atomic {
+		if err := checkAtomicDest(cfg.DestPath); err != nil {
+			return common.Wrap(err, "Atomic restore cannot replace the destination",
+				"Pass the directory itself as --dest rather than a symlink to it, or restore without --atomic")
+		}
+	}
+
 	// Check if destination directory is non-empty (safety check)
 	nonEmpty, err := isDirectoryNonEmpty(cfg.DestPath)
 	if err != nil {
@@ -63,19 +99,42 @@ func PerformRestore(ctx context.Context, cfg RestoreConfig) error {
 			"Check directory permissions")
 	}
 
-	if nonEmpty && !cfg.Force {
+	// Only the overwrite policy can replace existing files. Mirror restores
+	// confirm removals and atomic restores can keep the previous contents.
+	overwrite := cfg.OnConflict == archive.ConflictOverwrite
+	guarded := cfg.Mirror || (cfg.Atomic && cfg.KeepPrevious)
+	if nonEmpty && overwrite && !cfg.Force && !guarded {
 		return common.New(
 			fmt.Sprintf("Destination directory is not empty: %s", cfg.DestPath),
-			"Use --force to overwrite existing files (this will replace files with the same names)",
+			"Use --force to overwrite existing files (this will replace files with the same names), or --on-conflict=skip|newer|rename|fail to keep them",
 		)
 	}
 
-	// Ensure destination directory exists
-	if err := os.MkdirAll(cfg.DestPath, 0755); err != nil {
+	// Atomic restores extract into a staging directory; everything else in place
+	extractCfg := cfg
+	committed := false
+	if cfg.Atomic {
+		staging, err := createStagingDir(cfg.DestPath)
+		if err != nil {
+			return common.Wrap(err, "Cannot create staging directory for atomic restore",
+				"The parent of --dest must be writable")
+		}
+		defer func() {
+			if !committed {
+				os.RemoveAll(staging)
+			}
+		}()
+		extractCfg.DestPath = staging
+		if cfg.Verbose {
+			fmt.Printf("Staging restore in: %s\n", staging)
+		}
+	} else if err := os.MkdirAll(cfg.DestPath, 0755); err != nil {
 		return fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
-	if cfg.Verbose && nonEmpty && cfg.Force {
+	if cfg.Verbose && nonEmpty && cfg.Atomic {
+		fmt.Println("WARNING: Restoring to non-empty directory - existing contents will be replaced")
+	} else if cfg.Verbose && nonEmpty && overwrite {
 		fmt.Println("WARNING: Restoring to non-empty directory - existing files may be overwritten")
 	}
 
@@ -84,11 +143,76 @@ func PerformRestore(ctx context.Context, cfg RestoreConfig) error {
 		fmt.Printf("Destination: %s\n", cfg.DestPath)
 	}
 
+	// Ask before a mirror restore changes anything; this costs an extra pass over the backup
+	var confirmed map[string]bool
+	if cfg.Mirror && cfg.Confirm != nil {
+		extra, err := planMirror(ctx, cfg)
+		if err != nil {
+			return fmt.Errorf("restore pipeline failed: %w", err)
+		}
+		ok, err := cfg.Confirm(extra)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return common.New("Mirror restore cancelled", "Nothing was restored or removed")
+		}
+		confirmed = make(map[string]bool, len(extra))
+		for _, p := range extra {
+			confirmed[p] = true
+		}
+	}
+
 	// Execute the restore pipeline: FILE → DECRYPT → DECOMPRESS → EXTRACT
-	if err := executeRestorePipeline(ctx, cfg); err != nil {
+	result, err := executeRestorePipeline(ctx, extractCfg, false)
+	if err != nil {
+		if cfg.Atomic {
+			return common.Wrap(err, "Restore failed; the destination was not changed",
+				"Check the backup with 'secure-backup verify' and the free space next to --dest")
+		}
 		return fmt.Errorf("restore pipeline failed: %w", err)
 	}
 
+	if cfg.Mirror {
+		removed, err := removeExtraneous(cfg, result.Targets, confirmed)
+		if err != nil {
+			return common.Wrap(err, "Mirror restore could not remove files not in the backup",
+				"The backup was restored; remove the remaining files manually or re-run the restore")
+		}
+		fmt.Printf("Removed %d paths not in the backup\n", removed)
+	}
+
+	// Selective restores always report what matched
+	if len(cfg.Paths) > 0 || !cfg.Filter.IsEmpty() {
+		if result.Selected == 0 {
+			return common.New("No archive entries matched the selection",
+				"Archive paths start with the source directory name (e.g. documents/report.pdf); check --path, --include and --exclude")
+		}
+		fmt.Printf("Restored %d of %d archive entries matching the selection\n", result.Selected, result.Entries)
+	}
+
+	// Swap the complete staging directory into place
+	if cfg.Atomic {
+		committed, err = commitStaging(extractCfg.DestPath, cfg.DestPath, cfg.KeepPrevious)
+		if err != nil && committed {
+			return common.Wrap(err, "Backup restored, but the previous contents could not be cleaned up",
+				"Move or remove the directory named in the error manually")
+		}
+		if err != nil {
+			return common.Wrap(err, "Atomic restore could not replace the destination; it was not changed",
+				"Check permissions on --dest and its parent directory")
+		}
+		if cfg.Verbose && cfg.KeepPrevious && nonEmpty {
+			fmt.Printf("Previous contents kept in: %s\n", PreviousPath(cfg.DestPath))
+		}
+	}
+
+	// Restores that keep existing files always report what happened to them
+	if !overwrite || cfg.Verbose {
+		fmt.Printf("Files: %d created, %d overwritten, %d skipped, %d renamed\n",
+			result.Created, result.Overwritten, result.Skipped, result.Renamed)
+	}
+
 	if cfg.Verbose {
 		fmt.Printf("Restore completed successfully\n")
 	}
@@ -96,12 +220,47 @@ func PerformRestore(ctx context.Context, cfg RestoreConfig) error {
 	return nil
 }
 
-// executeRestorePipeline runs the restore pipeline
-func executeRestorePipeline(ctx context.Context, cfg RestoreConfig) error {
+// planMirror lists the paths a mirror restore would remove, reading the backup
+// without writing anything
+func planMirror(ctx context.Context, cfg RestoreConfig) ([]string, error) {
+	result, err := executeRestorePipeline(ctx, cfg, true)
+	if err != nil {
+		return nil, err
+	}
+	return archive.Extraneous(cfg.DestPath, result.Targets)
+}
+
+// removeExtraneous deletes paths under the destination that are not in targets.
+// When confirmed is non-nil, only paths in it are removed.
+func removeExtraneous(cfg RestoreConfig, targets map[string]bool, confirmed map[string]bool) (int, error) {
+	extra, err := archive.Extraneous(cfg.DestPath, targets)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, p := range extra {
+		if confirmed != nil && !confirmed[p] {
+			continue // Appeared after confirmation
+		}
+		if err := os.RemoveAll(p); err != nil {
+			return removed, err
+		}
+		if cfg.Verbose {
+			fmt.Printf("Removed: %s\n", p)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// executeRestorePipeline runs the restore pipeline. With listOnly, entries are
+// selected and their target paths collected, but nothing is written.
+func executeRestorePipeline(ctx context.Context, cfg RestoreConfig, listOnly bool) (archive.ExtractResult, error) {
 	// Step 1: Open encrypted backup file
 	backupFile, err := os.Open(cfg.BackupFile)
 	if err != nil {
-		return fmt.Errorf("failed to open backup file: %w", err)
+		return archive.ExtractResult{}, fmt.Errorf("failed to open backup file: %w", err)
 	}
 	defer backupFile.Close()
 
@@ -120,28 +279,58 @@ func executeRestorePipeline(ctx context.Context, cfg RestoreConfig) error {
 	// Step 2: Decrypt the file
 	decryptedReader, err := cfg.Encryptor.Decrypt(pr)
 	if err != nil {
-		return fmt.Errorf("decryption failed: %w", err)
+		return archive.ExtractResult{}, fmt.Errorf("decryption failed: %w", err)
 	}
 
 	// Step 3: Decompress
 	decompressedReader, err := cfg.Compressor.Decompress(decryptedReader)
 	if err != nil {
-		return fmt.Errorf("decompression failed: %w", err)
+		return archive.ExtractResult{}, fmt.Errorf("decompression failed: %w", err)
 	}
 
 	// Step 4: Extract tar archive
-	if err := archive.ExtractTar(decompressedReader, cfg.DestPath); err != nil {
+	extractCfg := archive.ExtractConfig{
+		SameOwner:    cfg.SameOwner,
+		NumericOwner: cfg.NumericOwner,
+		UIDMap:       cfg.UIDMap,
+		GIDMap:       cfg.GIDMap,
+		Xattrs:       cfg.Xattrs,
+		Devices:      cfg.Devices,
+		Paths:        cfg.Paths,
+		Filter:       cfg.Filter,
+
+		StripComponents: cfg.StripComponents,
+		Relocate:        cfg.Relocate,
+
+		OnConflict: cfg.OnConflict,
+
+		Mirror:   cfg.Mirror,
+		ListOnly: listOnly,
+	}
+	result, err := archive.Extract(decompressedReader, cfg.DestPath, extractCfg)
+	if err != nil {
 		pr.Finish()
-		return fmt.Errorf("tar extraction failed: %w", err)
+		return result, fmt.Errorf("tar extraction failed: %w", err)
+	}
+
+	// Read past the end of the archive so that trailing corruption and
+	// decryption integrity checks (performed at end of stream) are not missed
+	if _, err := io.CopyBuffer(io.Discard, decompressedReader, common.NewBuffer()); err != nil {
+		pr.Finish()
+		return result, fmt.Errorf("decompression failed: %w", err)
+	}
+	if _, err := io.CopyBuffer(io.Discard, decryptedReader, common.NewBuffer()); err != nil {
+		pr.Finish()
+		return result, fmt.Errorf("decryption failed: %w", err)
 	}
 	pr.Finish()
 
-	return nil
+	return result, nil
 }
 
 // dryRunRestore previews restore operation without executing
 // Note: Dry-run mode always shows verbose output for useful preview
-func dryRunRestore(cfg RestoreConfig) error {
+func dryRunRestore(ctx context.Context, cfg RestoreConfig) error {
 	// Validate backup file exists
 	fileInfo, err := os.Stat(cfg.BackupFile)
 	if err != nil {
@@ -152,6 +341,30 @@ func dryRunRestore(cfg RestoreConfig) error {
 	fmt.Println("[DRY RUN] Restore preview:")
 	fmt.Printf("[DRY RUN]   Backup file: %s (%s)\n", cfg.BackupFile, common.Size(fileInfo.Size()))
 	fmt.Printf("[DRY RUN]   Destination: %s\n", cfg.DestPath)
+	if len(cfg.Paths) > 0 {
+		fmt.Printf("[DRY RUN]   Paths: %s\n", strings.Join(cfg.Paths, ", "))
+	}
+	if len(cfg.Filter.Excludes) > 0 {
+		fmt.Printf("[DRY RUN]   Exclude patterns: %s\n", strings.Join(cfg.Filter.Excludes, ", "))
+	}
+	if len(cfg.Filter.Includes) > 0 {
+		fmt.Printf("[DRY RUN]   Include patterns: %s\n", strings.Join(cfg.Filter.Includes, ", "))
+	}
+	if cfg.StripComponents > 0 {
+		fmt.Printf("[DRY RUN]   Strip components: %d\n", cfg.StripComponents)
+	}
+	if cfg.OnConflict != archive.ConflictOverwrite {
+		fmt.Printf("[DRY RUN]   Existing files: %s\n", cfg.OnConflict)
+	}
+	if cfg.Atomic {
+		fmt.Printf("[DRY RUN]   Atomic: staged next to destination, swapped in on success\n")
+		if cfg.KeepPrevious {
+			fmt.Printf("[DRY RUN]   Previous contents: %s\n", PreviousPath(cfg.DestPath))
+		}
+	}
+	for _, rule := range cfg.Relocate {
+		fmt.Printf("[DRY RUN]   Relocate: %s -> %s\n", rule.Old, filepath.Join(cfg.DestPath, rule.New))
+	}
 	fmt.Println("[DRY RUN]")
 	fmt.Println("[DRY RUN] Pipeline stages that would execute:")
 	fmt.Printf("[DRY RUN]   - DECRYPT - Decrypt backup file with %s\n", cfg.Encryptor.Type())
@@ -160,6 +373,20 @@ func dryRunRestore(cfg RestoreConfig) error {
 	}
 	fmt.Println("[DRY RUN]   - EXTRACT - Extract tar archive to destination")
 
+	// Mirror restores read the backup to list what would be removed
+	if cfg.Mirror {
+		extra, err := planMirror(ctx, cfg)
+		if err != nil {
+			return fmt.Errorf("restore pipeline failed: %w", err)
+		}
+		fmt.Println("[DRY RUN]   - MIRROR - Remove paths not in the backup")
+		fmt.Println("[DRY RUN]")
+		fmt.Printf("[DRY RUN] Would remove %d paths:\n", len(extra))
+		for _, p := range extra {
+			fmt.Printf("[DRY RUN]   %s\n", p)
+		}
+	}
+
 	return nil
 }
 
@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/icemarkom/secure-backup/internal/archive"
 	"github.com/icemarkom/secure-backup/internal/compress"
 	"github.com/icemarkom/secure-backup/internal/encrypt"
 	"github.com/stretchr/testify/assert"
@@ -613,3 +614,58 @@ func TestPerformRestore_NonexistentDestination_WithoutForce(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "test content", string(content))
 }
+
+// TestPerformRestore_NonEmptyDestination_SkipPolicy tests that policies keeping existing files need no --force
+func TestPerformRestore_NonEmptyDestination_SkipPolicy(t *testing.T) {
+	tempRoot := t.TempDir()
+
+	sourceDir := filepath.Join(tempRoot, "source")
+	require.NoError(t, os.Mkdir(sourceDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "kept.txt"), []byte("archived"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "lost.txt"), []byte("archived"), 0644))
+
+	keyPaths, err := generateTestKeys(t, tempRoot)
+	if err != nil {
+		t.Skip("Skipping test: GPG key generation failed")
+	}
+
+	compressor, err := compress.NewCompressor(compress.Config{Method: compress.Gzip, Level: 6})
+	require.NoError(t, err)
+	encryptor, err := encrypt.NewEncryptor(encrypt.Config{
+		Method:     encrypt.GPG,
+		PublicKey:  keyPaths.PublicKey,
+		PrivateKey: keyPaths.PrivateKey,
+	})
+	require.NoError(t, err)
+
+	backupPath, _, err := PerformBackup(context.Background(), Config{
+		SourcePath: sourceDir,
+		DestDir:    filepath.Join(tempRoot, "backups"),
+		Encryptor:  encryptor,
+		Compressor: compressor,
+	})
+	require.NoError(t, err)
+
+	// Live destination: one file changed since the backup, one lost
+	restoreDir := filepath.Join(tempRoot, "restore")
+	require.NoError(t, os.MkdirAll(filepath.Join(restoreDir, "source"), 0755))
+	keptFile := filepath.Join(restoreDir, "source", "kept.txt")
+	require.NoError(t, os.WriteFile(keptFile, []byte("live"), 0644))
+
+	err = PerformRestore(context.Background(), RestoreConfig{
+		BackupFile: backupPath,
+		DestPath:   restoreDir,
+		Encryptor:  encryptor,
+		Compressor: compressor,
+		OnConflict: archive.ConflictSkip,
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(keptFile)
+	require.NoError(t, err)
+	assert.Equal(t, "live", string(content))
+
+	content, err = os.ReadFile(filepath.Join(restoreDir, "source", "lost.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "archived", string(content))
+}
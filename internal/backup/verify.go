@@ -18,6 +18,8 @@ package backup
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -30,12 +32,13 @@ import (
 
 // VerifyConfig holds configuration for verify operations
 type VerifyConfig struct {
-	BackupFile string
-	Encryptor  encrypt.Encryptor
-	Compressor compress.Compressor
-	Quick      bool
-	Verbose    bool
-	DryRun     bool
+	BackupFile  string
+	Encryptor   encrypt.Encryptor
+	Compressor  compress.Compressor
+	Quick       bool
+	Verbose     bool
+	DryRun      bool
+	TarChecksum string // Expected SHA256 of the uncompressed tar stream (empty = not checked)
 }
 
 // PerformVerify verifies the integrity of a backup file
@@ -144,14 +147,24 @@ func fullVerify(ctx context.Context, cfg VerifyConfig) error {
 	}
 
 	// Read through the entire stream to verify integrity
-	bytesRead, err := io.CopyBuffer(io.Discard, decompressedReader, common.NewBuffer())
+	tarHash := sha256.New()
+	bytesRead, err := io.CopyBuffer(tarHash, decompressedReader, common.NewBuffer())
 	pr.Finish()
 	if err != nil {
 		return fmt.Errorf("archive verification failed: %w", err)
 	}
 
+	tarChecksum := hex.EncodeToString(tarHash.Sum(nil))
+	if cfg.TarChecksum != "" && cfg.TarChecksum != tarChecksum {
+		return common.New(
+			fmt.Sprintf("Archive checksum mismatch: expected %s, got %s", cfg.TarChecksum, tarChecksum),
+			"The decrypted archive differs from the one recorded in the manifest",
+		)
+	}
+
 	if cfg.Verbose {
 		fmt.Printf("✓ Successfully verified %s of decompressed data\n", common.Size(bytesRead))
+		fmt.Printf("✓ Archive SHA256: %s\n", tarChecksum)
 		fmt.Println("✓ Full verification passed")
 	}
 
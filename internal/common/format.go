@@ -19,6 +19,8 @@ package common
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -36,6 +38,37 @@ func Size(bytes int64) string {
 	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+// ParseSize parses a byte count with an optional binary suffix, the inverse
+// of Size: "512", "64K", "32MiB", "1G". Suffixes are case-insensitive.
+func ParseSize(s string) (int64, error) {
+	num := strings.TrimSpace(s)
+	upper := strings.ToUpper(num)
+	shift := 0
+	for i, suffix := range []string{"K", "M", "G", "T"} {
+		for _, form := range []string{suffix + "IB", suffix + "B", suffix} {
+			if strings.HasSuffix(upper, form) {
+				num, shift = strings.TrimSpace(num[:len(num)-len(form)]), 10*(i+1)
+				break
+			}
+		}
+		if shift != 0 {
+			break
+		}
+	}
+	if shift == 0 && strings.HasSuffix(upper, "B") {
+		num = strings.TrimSpace(num[:len(num)-1])
+	}
+
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 512, 64K, 32MiB)", s)
+	}
+	if n > (1<<63-1)>>shift {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return n << shift, nil
+}
+
 // Age formats a duration as a human-readable age string (e.g., "3d12h", "5h", "30m").
 func Age(d time.Duration) string {
 	days := int(d.Hours() / 24)
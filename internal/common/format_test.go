@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSize(t *testing.T) {
@@ -64,6 +65,36 @@ func TestSize(t *testing.T) {
 	}
 }
 
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"64K", 64 << 10},
+		{"64kb", 64 << 10},
+		{"32MiB", 32 << 20},
+		{"32 MiB", 32 << 20},
+		{"1g", 1 << 30},
+		{"2T", 2 << 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSize(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, input := range []string{"", "MiB", "-1", "1.5M", "10X", "9999999T"} {
+		_, err := ParseSize(input)
+		assert.Error(t, err, input)
+	}
+}
+
 func TestAge(t *testing.T) {
 	tests := []struct {
 		name string
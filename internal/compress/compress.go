@@ -35,6 +35,8 @@ const (
 	Lz4
 	// None disables compression (passthrough).
 	None
+	// S2 is the S2 compression method (Snappy-compatible, parallel).
+	S2
 )
 
 // String names for compression methods, used in CLI flags and user-facing output.
@@ -42,6 +44,7 @@ const (
 	MethodGzip = "gzip"
 	MethodZstd = "zstd"
 	MethodLz4  = "lz4"
+	MethodS2   = "s2"
 	MethodNone = "none"
 )
 
@@ -54,6 +57,8 @@ func (m Method) String() string {
 		return MethodZstd
 	case Lz4:
 		return MethodLz4
+	case S2:
+		return MethodS2
 	case None:
 		return MethodNone
 	default:
@@ -63,7 +68,7 @@ func (m Method) String() string {
 
 // ValidMethods returns all supported compression methods.
 func ValidMethods() []Method {
-	return []Method{Gzip, Zstd, Lz4, None}
+	return []Method{Gzip, Zstd, Lz4, S2, None}
 }
 
 // ValidMethodNames returns a comma-separated string of valid method names.
@@ -77,6 +82,74 @@ func ValidMethodNames() string {
 	return strings.Join(names, ", ")
 }
 
+// levelBounds returns the lowest and highest level the method accepts. Level 0
+// is accepted as well and selects DefaultLevel. ok is false for None.
+func (m Method) levelBounds() (lo, hi int, ok bool) {
+	switch m {
+	case Gzip:
+		return 1, 9, true
+	case Zstd:
+		return 1, 4, true
+	case Lz4:
+		return 0, 9, true
+	case S2:
+		return 1, 3, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// LevelRange describes the compression levels accepted by the method, for
+// help text and error messages. Empty for None, which has no levels.
+func (m Method) LevelRange() string {
+	lo, hi, ok := m.levelBounds()
+	if !ok {
+		return ""
+	}
+	fastest := ""
+	if m.DefaultLevel() == lo {
+		fastest = ", fastest"
+	}
+	return fmt.Sprintf("%d-%d (default %d%s)", lo, hi, m.DefaultLevel(), fastest)
+}
+
+// checkLevel rejects levels outside LevelRange, other than 0 for the default.
+// Every constructor validates its level here, so the accepted levels are the
+// advertised ones.
+func checkLevel(m Method, level int) error {
+	lo, hi, _ := m.levelBounds()
+	if level != 0 && (level < lo || level > hi) {
+		return fmt.Errorf("invalid %s compression level: %d (must be %s, or 0 for the default)", m, level, m.LevelRange())
+	}
+	return nil
+}
+
+// DefaultLevel returns the level a compressor uses when Config.Level is 0.
+func (m Method) DefaultLevel() int {
+	switch m {
+	case Gzip:
+		return 6
+	case Zstd:
+		return 2
+	case S2:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// LevelRanges returns the valid levels of every method that has levels,
+// e.g. "gzip 1-9 (default 6), zstd 1-4 (default 2), ...".
+func LevelRanges() string {
+	var ranges []string
+	for _, m := range ValidMethods() {
+		if r := m.LevelRange(); r != "" {
+			ranges = append(ranges, m.String()+" "+r)
+		}
+	}
+	return strings.Join(ranges, ", ")
+}
+
 // parseMap maps lowercase method name → Method, built at init from ValidMethods().
 var parseMap map[string]Method
 
@@ -150,20 +223,57 @@ type Compressor interface {
 
 // Config holds compression configuration
 type Config struct {
-	Method Method // Compression method
-	Level  int    // Compression level (method-specific)
+	Method  Method // Compression method
+	Level   int    // Compression level (method-specific, 0 = method default)
+	Threads int    // Concurrent compression workers (0 = all CPUs for gzip, zstd and s2, one for lz4; ignored by none)
+	Window  int    // Zstd window size in bytes, a power of two up to MaxZstdWindow (0 = level default)
 }
 
 // NewCompressor creates a compressor based on config
 func NewCompressor(cfg Config) (Compressor, error) {
+	if cfg.Threads < 0 {
+		return nil, fmt.Errorf("invalid compression thread count: %d (must be 0 or more)", cfg.Threads)
+	}
+	if cfg.Window != 0 && cfg.Method != Zstd {
+		return nil, fmt.Errorf("window size only applies to zstd, not %s", cfg.Method)
+	}
+
 	switch cfg.Method {
 	case Gzip:
-		return NewGzipCompressor(cfg.Level)
+		c, err := NewGzipCompressor(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		c.threads = cfg.Threads
+		return c, nil
 	case Zstd:
-		return NewZstdCompressor(cfg.Level)
+		c, err := NewZstdCompressor(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		if err := ValidateZstdWindow(cfg.Window); err != nil {
+			return nil, err
+		}
+		c.threads, c.window = cfg.Threads, cfg.Window
+		return c, nil
 	case Lz4:
-		return NewLz4Compressor(cfg.Level)
+		c, err := NewLz4Compressor(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		c.threads = cfg.Threads
+		return c, nil
+	case S2:
+		c, err := NewS2Compressor(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		c.threads = cfg.Threads
+		return c, nil
 	case None:
+		if cfg.Level != 0 {
+			return nil, fmt.Errorf("invalid none compression level: %d (none has no levels)", cfg.Level)
+		}
 		return NewNoneCompressor(), nil
 	default:
 		return nil, fmt.Errorf("unknown compression method: %s", cfg.Method)
@@ -118,15 +118,18 @@ func benchRatio(b *testing.B, method Method) {
 func BenchmarkGzipCompress(b *testing.B) { benchCompress(b, Gzip) }
 func BenchmarkZstdCompress(b *testing.B) { benchCompress(b, Zstd) }
 func BenchmarkLz4Compress(b *testing.B)  { benchCompress(b, Lz4) }
+func BenchmarkS2Compress(b *testing.B)   { benchCompress(b, S2) }
 
 // --- Decompress benchmarks ---
 
 func BenchmarkGzipDecompress(b *testing.B) { benchDecompress(b, Gzip) }
 func BenchmarkZstdDecompress(b *testing.B) { benchDecompress(b, Zstd) }
 func BenchmarkLz4Decompress(b *testing.B)  { benchDecompress(b, Lz4) }
+func BenchmarkS2Decompress(b *testing.B)   { benchDecompress(b, S2) }
 
 // --- Compression ratio benchmarks ---
 
 func BenchmarkGzipRatio(b *testing.B) { benchRatio(b, Gzip) }
 func BenchmarkZstdRatio(b *testing.B) { benchRatio(b, Zstd) }
 func BenchmarkLz4Ratio(b *testing.B)  { benchRatio(b, Lz4) }
+func BenchmarkS2Ratio(b *testing.B)   { benchRatio(b, S2) }
@@ -17,6 +17,9 @@
 package compress
 
 import (
+	"bytes"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -32,6 +35,7 @@ func TestMethod_String(t *testing.T) {
 		{"Gzip", Gzip, "gzip"},
 		{"Zstd", Zstd, "zstd"},
 		{"Lz4", Lz4, "lz4"},
+		{"S2", S2, "s2"},
 		{"None", None, "none"},
 		{"unknown", Method(99), "unknown(99)"},
 	}
@@ -53,10 +57,12 @@ func TestParseMethod(t *testing.T) {
 		{"gzip lowercase", "gzip", Gzip, false},
 		{"zstd lowercase", "zstd", Zstd, false},
 		{"lz4 lowercase", "lz4", Lz4, false},
+		{"s2 lowercase", "s2", S2, false},
 		{"none lowercase", "none", None, false},
 		{"GZIP uppercase", "GZIP", Gzip, false},
 		{"ZSTD uppercase", "ZSTD", Zstd, false},
 		{"LZ4 uppercase", "LZ4", Lz4, false},
+		{"S2 uppercase", "S2", S2, false},
 		{"NONE uppercase", "NONE", None, false},
 		{"Gzip mixed case", "Gzip", Gzip, false},
 		{"Zstd mixed case", "Zstd", Zstd, false},
@@ -82,10 +88,11 @@ func TestParseMethod(t *testing.T) {
 
 func TestValidMethods(t *testing.T) {
 	methods := ValidMethods()
-	assert.Len(t, methods, 4)
+	assert.Len(t, methods, 5)
 	assert.Contains(t, methods, Gzip)
 	assert.Contains(t, methods, Zstd)
 	assert.Contains(t, methods, Lz4)
+	assert.Contains(t, methods, S2)
 	assert.Contains(t, methods, None)
 }
 
@@ -94,8 +101,76 @@ func TestValidMethodNames(t *testing.T) {
 	assert.Contains(t, names, MethodGzip)
 	assert.Contains(t, names, MethodZstd)
 	assert.Contains(t, names, MethodLz4)
+	assert.Contains(t, names, MethodS2)
 	assert.Contains(t, names, MethodNone)
-	assert.Equal(t, "gzip, zstd, lz4, none", names)
+	assert.Equal(t, "gzip, zstd, lz4, s2, none", names)
+}
+
+func TestMethod_Levels(t *testing.T) {
+	assert.Equal(t, 6, Gzip.DefaultLevel())
+	assert.Equal(t, 2, Zstd.DefaultLevel())
+	assert.Equal(t, 0, Lz4.DefaultLevel())
+	assert.Equal(t, 1, S2.DefaultLevel())
+	assert.Empty(t, None.LevelRange())
+	assert.Equal(t, "gzip 1-9 (default 6), zstd 1-4 (default 2), lz4 0-9 (default 0, fastest), s2 1-3 (default 1, fastest)", LevelRanges())
+
+	// Every documented default is accepted, and so is 0
+	for _, m := range ValidMethods() {
+		_, err := NewCompressor(Config{Method: m, Level: m.DefaultLevel()})
+		assert.NoError(t, err, m.String())
+		_, err = NewCompressor(Config{Method: m})
+		assert.NoError(t, err, m.String())
+	}
+}
+
+func TestNewCompressor_InvalidLevel(t *testing.T) {
+	tests := []struct {
+		method Method
+		level  int
+	}{
+		{Gzip, 10},
+		{Gzip, -1},
+		{Gzip, -2},
+		{Zstd, -1},
+		{Zstd, 5},
+		{Lz4, -1},
+		{Lz4, 10},
+		{S2, 4},
+		{None, 1},
+	}
+
+	for _, tt := range tests {
+		_, err := NewCompressor(Config{Method: tt.method, Level: tt.level})
+		require.Error(t, err, "%s level %d", tt.method, tt.level)
+		assert.Contains(t, err.Error(), "invalid "+tt.method.String()+" compression level")
+		assert.Contains(t, err.Error(), tt.method.LevelRange(), "the error quotes the advertised range")
+	}
+}
+
+func TestNewCompressor_Threads(t *testing.T) {
+	// Several blocks, so concurrent compressors all get work
+	data := []byte(strings.Repeat("2026-02-17T22:30:00Z INFO backup.pipeline: processing block\n", 80000))
+
+	for _, m := range ValidMethods() {
+		t.Run(m.String(), func(t *testing.T) {
+			comp, err := NewCompressor(Config{Method: m, Threads: 3})
+			require.NoError(t, err)
+
+			compressed, err := comp.Compress(bytes.NewReader(data))
+			require.NoError(t, err)
+			encoded, err := io.ReadAll(compressed)
+			require.NoError(t, err)
+
+			// Output is read by the default decompressor
+			plain, err := NewCompressor(Config{Method: m})
+			require.NoError(t, err)
+			decompressed, err := plain.Decompress(bytes.NewReader(encoded))
+			require.NoError(t, err)
+			decoded, err := io.ReadAll(decompressed)
+			require.NoError(t, err)
+			assert.Equal(t, data, decoded)
+		})
+	}
 }
 
 func TestGzipCompressor_Type(t *testing.T) {
@@ -123,11 +198,14 @@ func TestResolveMethod(t *testing.T) {
 		{"zstd age", "backup_test_20260101_120000.tar.zst.age", Zstd, false},
 		{"lz4 gpg", "backup_test_20260101_120000.tar.lz4.gpg", Lz4, false},
 		{"lz4 age", "backup_test_20260101_120000.tar.lz4.age", Lz4, false},
+		{"s2 gpg", "backup_test_20260101_120000.tar.s2.gpg", S2, false},
+		{"s2 age", "backup_test_20260101_120000.tar.s2.age", S2, false},
 		{"none gpg", "backup_test_20260101_120000.tar.gpg", None, false},
 		{"none age", "backup_test_20260101_120000.tar.age", None, false},
 		{"full path gzip", "/backups/daily/backup_data.tar.gz.gpg", Gzip, false},
 		{"full path zstd", "/backups/daily/backup_data.tar.zst.gpg", Zstd, false},
 		{"full path lz4", "/backups/daily/backup_data.tar.lz4.gpg", Lz4, false},
+		{"full path s2", "/backups/daily/backup_data.tar.s2.gpg", S2, false},
 		{"full path none", "/backups/daily/backup_data.tar.gpg", None, false},
 		{"unknown extension", "backup.zip", Method(0), true},
 		{"no extension", "backup", Method(0), true},
@@ -24,24 +24,27 @@ import (
 	gzip "github.com/klauspost/pgzip"
 )
 
+// gzipBlockSize is the pgzip block size, kept at its default when only the
+// number of concurrent blocks is set
+const gzipBlockSize = 1 << 20
+
 // GzipCompressor implements the Compressor interface using gzip
 type GzipCompressor struct {
-	level int
+	level   int
+	threads int // Blocks compressed concurrently (0 = GOMAXPROCS)
 }
 
 // NewGzipCompressor creates a new gzip compressor with the specified level.
 // If level is 0, uses gzip.DefaultCompression (level 6).
-// Valid levels: -1 (DefaultCompression), 0 (NoCompression), 1-9 (BestSpeed to BestCompression)
+// Valid levels: 1-9 (BestSpeed to BestCompression).
 func NewGzipCompressor(level int) (*GzipCompressor, error) {
+	if err := checkLevel(Gzip, level); err != nil {
+		return nil, err
+	}
 	if level == 0 {
 		level = gzip.DefaultCompression // Level 6
 	}
 
-	// Validate level
-	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
-		return nil, fmt.Errorf("invalid gzip compression level: %d (must be -1 to 9)", level)
-	}
-
 	return &GzipCompressor{level: level}, nil
 }
 
@@ -58,6 +61,12 @@ func (c *GzipCompressor) Compress(input io.Reader) (io.Reader, error) {
 			return
 		}
 		defer gw.Close()
+		if c.threads > 0 {
+			if err := gw.SetConcurrency(gzipBlockSize, c.threads); err != nil {
+				pw.CloseWithError(fmt.Errorf("failed to set gzip concurrency: %w", err))
+				return
+			}
+		}
 
 		if _, err := io.CopyBuffer(gw, input, common.NewBuffer()); err != nil {
 			pw.CloseWithError(fmt.Errorf("compression failed: %w", err))
@@ -90,6 +90,8 @@ func TestGzipCompressor_InvalidLevel(t *testing.T) {
 		level int
 	}{
 		{"too low", -5},
+		{"default compression constant", -1},
+		{"huffman only", -2},
 		{"too high", 15},
 	}
 
@@ -104,7 +106,7 @@ func TestGzipCompressor_InvalidLevel(t *testing.T) {
 
 func TestGzipCompressor_ValidLevels(t *testing.T) {
 	// Test all valid compression levels
-	validLevels := []int{-1, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	validLevels := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
 
 	for _, level := range validLevels {
 		t.Run(string(rune(level)), func(t *testing.T) {
@@ -155,6 +157,23 @@ func TestNewCompressor(t *testing.T) {
 			expectError: true,
 			errorMsg:    "unknown compression method",
 		},
+		{
+			name:        "gzip with threads",
+			config:      Config{Method: Gzip, Threads: 4},
+			expectError: false,
+		},
+		{
+			name:        "negative threads",
+			config:      Config{Method: Gzip, Threads: -1},
+			expectError: true,
+			errorMsg:    "invalid compression thread count",
+		},
+		{
+			name:        "window on gzip",
+			config:      Config{Method: Gzip, Window: 1 << 20},
+			expectError: true,
+			errorMsg:    "window size only applies to zstd",
+		},
 	}
 
 	for _, tt := range tests {
@@ -26,15 +26,16 @@ import (
 
 // Lz4Compressor implements the Compressor interface using lz4.
 type Lz4Compressor struct {
-	level lz4.CompressionLevel
+	level   lz4.CompressionLevel
+	threads int // Concurrent block compressors (0 = sequential)
 }
 
 // NewLz4Compressor creates a new lz4 compressor with the specified level.
 // If level is 0, uses lz4.Fast (default).
 // Valid levels: 0 (fast/default), 1-9 (increasing compression).
 func NewLz4Compressor(level int) (*Lz4Compressor, error) {
-	if level < 0 || level > 9 {
-		return nil, fmt.Errorf("invalid lz4 compression level: %d (must be 0-9)", level)
+	if err := checkLevel(Lz4, level); err != nil {
+		return nil, err
 	}
 
 	var compLevel lz4.CompressionLevel
@@ -56,7 +57,14 @@ func (c *Lz4Compressor) Compress(input io.Reader) (io.Reader, error) {
 		defer pw.Close()
 
 		enc := lz4.NewWriter(pw)
-		enc.Apply(lz4.CompressionLevelOption(c.level))
+		opts := []lz4.Option{lz4.CompressionLevelOption(c.level)}
+		if c.threads > 0 {
+			opts = append(opts, lz4.ConcurrencyOption(c.threads))
+		}
+		if err := enc.Apply(opts...); err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to configure lz4 writer: %w", err))
+			return
+		}
 
 		if _, err := io.CopyBuffer(enc, input, common.NewBuffer()); err != nil {
 			pw.CloseWithError(fmt.Errorf("compression failed: %w", err))
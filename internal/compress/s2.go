@@ -0,0 +1,112 @@
+// Copyright 2026 Marko Milivojevic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package compress
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/icemarkom/secure-backup/internal/common"
+	"github.com/klauspost/compress/s2"
+)
+
+// s2MaxDecoders caps concurrent S2 block decoders. Each holds up to two 4 MiB
+// blocks, so decompression memory stays bounded on hosts with many cores.
+const s2MaxDecoders = 8
+
+// S2Compressor implements the Compressor interface using S2, a faster
+// extension of Snappy that also reads Snappy streams.
+type S2Compressor struct {
+	level   int // 1 (fast), 2 (better), 3 (best)
+	threads int // Concurrent block encoders (0 = GOMAXPROCS)
+}
+
+// NewS2Compressor creates a new S2 compressor with the specified level.
+// If level is 0, uses level 1 (fastest, default).
+// Valid levels: 1 (default), 2 (better compression), 3 (best compression).
+func NewS2Compressor(level int) (*S2Compressor, error) {
+	if err := checkLevel(S2, level); err != nil {
+		return nil, err
+	}
+	if level == 0 {
+		level = 1
+	}
+
+	return &S2Compressor{level: level}, nil
+}
+
+// Compress compresses the input stream using S2.
+func (c *S2Compressor) Compress(input io.Reader) (io.Reader, error) {
+	pr, pw := io.Pipe()
+
+	go func() {
+		defer pw.Close()
+
+		var opts []s2.WriterOption
+		switch c.level {
+		case 2:
+			opts = append(opts, s2.WriterBetterCompression())
+		case 3:
+			opts = append(opts, s2.WriterBestCompression())
+		}
+		if c.threads > 0 {
+			opts = append(opts, s2.WriterConcurrency(c.threads))
+		}
+		enc := s2.NewWriter(pw, opts...)
+		defer enc.Close()
+
+		if _, err := io.CopyBuffer(enc, input, common.NewBuffer()); err != nil {
+			pw.CloseWithError(fmt.Errorf("compression failed: %w", err))
+			return
+		}
+
+		if err := enc.Close(); err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to close s2 writer: %w", err))
+			return
+		}
+	}()
+
+	return pr, nil
+}
+
+// Decompress decompresses the input stream using S2, decoding blocks in parallel.
+func (c *S2Compressor) Decompress(input io.Reader) (io.Reader, error) {
+	pr, pw := io.Pipe()
+
+	go func() {
+		defer pw.Close()
+
+		dec := s2.NewReader(input)
+		if _, err := dec.DecodeConcurrent(pw, min(runtime.GOMAXPROCS(0), s2MaxDecoders)); err != nil {
+			pw.CloseWithError(fmt.Errorf("decompression failed: %w", err))
+			return
+		}
+	}()
+
+	return pr, nil
+}
+
+// Type returns the compression method type.
+func (c *S2Compressor) Type() Method {
+	return S2
+}
+
+// Extension returns the file extension for S2 compressed files.
+func (c *S2Compressor) Extension() string {
+	return ".s2"
+}
@@ -24,15 +24,26 @@ import (
 	"github.com/klauspost/compress/zstd"
 )
 
+// MaxZstdWindow is the largest zstd window accepted for compression, and the
+// largest a decompressor allocates (128 MiB, the window of zstd --long).
+// Larger windows in an archive are rejected rather than risking unbounded memory.
+const MaxZstdWindow = 1 << 27
+
 // ZstdCompressor implements the Compressor interface using zstd.
 type ZstdCompressor struct {
-	level zstd.EncoderLevel
+	level   zstd.EncoderLevel
+	threads int // Concurrent encoders (0 = GOMAXPROCS)
+	window  int // Window size in bytes (0 = level default, at most 8 MiB)
 }
 
 // NewZstdCompressor creates a new zstd compressor with the specified level.
 // If level is 0, uses zstd.SpeedDefault.
 // Valid levels: 1 (fastest), 2 (default), 3 (better compression), 4 (best compression).
 func NewZstdCompressor(level int) (*ZstdCompressor, error) {
+	if err := checkLevel(Zstd, level); err != nil {
+		return nil, err
+	}
+
 	var encLevel zstd.EncoderLevel
 	switch level {
 	case 0, 2:
@@ -43,8 +54,6 @@ func NewZstdCompressor(level int) (*ZstdCompressor, error) {
 		encLevel = zstd.SpeedBetterCompression
 	case 4:
 		encLevel = zstd.SpeedBestCompression
-	default:
-		return nil, fmt.Errorf("invalid zstd compression level: %d (must be 0-4)", level)
 	}
 
 	return &ZstdCompressor{level: encLevel}, nil
@@ -57,7 +66,14 @@ func (c *ZstdCompressor) Compress(input io.Reader) (io.Reader, error) {
 	go func() {
 		defer pw.Close()
 
-		enc, err := zstd.NewWriter(pw, zstd.WithEncoderLevel(c.level))
+		opts := []zstd.EOption{zstd.WithEncoderLevel(c.level)}
+		if c.threads > 0 {
+			opts = append(opts, zstd.WithEncoderConcurrency(c.threads))
+		}
+		if c.window > 0 {
+			opts = append(opts, zstd.WithWindowSize(c.window))
+		}
+		enc, err := zstd.NewWriter(pw, opts...)
 		if err != nil {
 			pw.CloseWithError(fmt.Errorf("failed to create zstd writer: %w", err))
 			return
@@ -80,7 +96,7 @@ func (c *ZstdCompressor) Compress(input io.Reader) (io.Reader, error) {
 
 // Decompress decompresses the input stream using zstd.
 func (c *ZstdCompressor) Decompress(input io.Reader) (io.Reader, error) {
-	dec, err := zstd.NewReader(input)
+	dec, err := zstd.NewReader(input, zstd.WithDecoderMaxWindow(MaxZstdWindow))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create zstd reader: %w", err)
 	}
@@ -100,6 +116,18 @@ func (c *ZstdCompressor) Decompress(input io.Reader) (io.Reader, error) {
 	return pr, nil
 }
 
+// ValidateZstdWindow checks a zstd Config.Window; 0 keeps the default.
+func ValidateZstdWindow(n int) error {
+	if n == 0 {
+		return nil
+	}
+	if n < zstd.MinWindowSize || n > MaxZstdWindow || n&(n-1) != 0 {
+		return fmt.Errorf("invalid zstd window size: %d (must be a power of two from %s to %s)",
+			n, common.Size(zstd.MinWindowSize), common.Size(MaxZstdWindow))
+	}
+	return nil
+}
+
 // Type returns the compression method type.
 func (c *ZstdCompressor) Type() Method {
 	return Zstd
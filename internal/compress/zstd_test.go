@@ -19,9 +19,12 @@ package compress
 import (
 	"bytes"
 	"io"
+	"math/rand/v2"
 	"strings"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -150,6 +153,66 @@ func TestZstdCompressor_Type(t *testing.T) {
 	assert.Equal(t, Zstd, compressor.Type())
 }
 
+func TestNewCompressor_ZstdWindow(t *testing.T) {
+	for _, window := range []int{1 << 10, 1 << 20, MaxZstdWindow} {
+		_, err := NewCompressor(Config{Method: Zstd, Window: window})
+		assert.NoError(t, err, window)
+	}
+	for _, window := range []int{512, 3 << 20, MaxZstdWindow * 2, -1} {
+		_, err := NewCompressor(Config{Method: Zstd, Window: window})
+		require.Error(t, err, window)
+		assert.Contains(t, err.Error(), "invalid zstd window size")
+	}
+}
+
+func TestZstdCompressor_LongWindow(t *testing.T) {
+	// Random data repeated further apart than the default window (8 MiB)
+	block := make([]byte, 9<<20)
+	_, err := rand.NewChaCha8([32]byte{}).Read(block)
+	require.NoError(t, err)
+	data := append(bytes.Clone(block), block...)
+
+	compress := func(cfg Config) []byte {
+		comp, err := NewCompressor(cfg)
+		require.NoError(t, err)
+		r, err := comp.Compress(bytes.NewReader(data))
+		require.NoError(t, err)
+		out, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return out
+	}
+	standard := compress(Config{Method: Zstd})
+	long := compress(Config{Method: Zstd, Window: 16 << 20})
+	assert.Less(t, len(long), len(standard)*2/3, "the repeat is found with a larger window")
+
+	// Readable by the default (bounded) decompressor
+	comp, err := NewCompressor(Config{Method: Zstd})
+	require.NoError(t, err)
+	r, err := comp.Decompress(bytes.NewReader(long))
+	require.NoError(t, err)
+	decoded, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(data, decoded))
+}
+
+func TestZstdCompressor_DecompressWindowTooLarge(t *testing.T) {
+	// A stream declaring a window beyond MaxZstdWindow is rejected
+	var buf bytes.Buffer
+	enc, err := zstd.NewWriter(&buf, zstd.WithWindowSize(MaxZstdWindow*2))
+	require.NoError(t, err)
+	_, err = enc.Write(bytes.Repeat([]byte("data"), 1<<20))
+	require.NoError(t, err)
+	require.NoError(t, enc.Close())
+
+	comp, err := NewCompressor(Config{Method: Zstd})
+	require.NoError(t, err)
+	r, err := comp.Decompress(&buf)
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "window size")
+}
+
 func TestNewCompressor_Zstd(t *testing.T) {
 	compressor, err := NewCompressor(Config{Method: Zstd, Level: 0})
 	require.NoError(t, err)
@@ -71,16 +71,44 @@ func ManifestPath(backupPath string) string {
 
 // Manifest represents metadata and integrity information for a backup
 type Manifest struct {
-	CreatedAt             time.Time `json:"created_at"`
-	CreatedBy             CreatedBy `json:"created_by"`
-	SourcePath            string    `json:"source_path"`
-	BackupFile            string    `json:"backup_file"`
-	Compression           string    `json:"compression"`
-	Encryption            string    `json:"encryption"`
-	ChecksumAlgorithm     string    `json:"checksum_algorithm"`
-	ChecksumValue         string    `json:"checksum_value"`
-	UncompressedSizeBytes int64     `json:"uncompressed_size_bytes"`
-	CompressedSizeBytes   int64     `json:"compressed_size_bytes"`
+	CreatedAt             time.Time   `json:"created_at"`
+	CreatedBy             CreatedBy   `json:"created_by"`
+	SourcePath            string      `json:"source_path"`
+	SourcePaths           []string    `json:"source_paths,omitempty"`
+	BackupFile            string      `json:"backup_file"`
+	Compression           string      `json:"compression"`
+	CompressionLevel      *int        `json:"compression_level,omitempty"`
+	Encryption            string      `json:"encryption"`
+	ChecksumAlgorithm     string      `json:"checksum_algorithm"`
+	ChecksumValue         string      `json:"checksum_value"`
+	UncompressedSizeBytes int64       `json:"uncompressed_size_bytes"`
+	CompressedSizeBytes   int64       `json:"compressed_size_bytes"`
+	ExcludePatterns       []string    `json:"exclude_patterns,omitempty"`
+	IncludePatterns       []string    `json:"include_patterns,omitempty"`
+	SkippedMounts         []string    `json:"skipped_mounts,omitempty"`
+	ChangedFiles          []string    `json:"changed_files,omitempty"`
+	VanishedFiles         []string    `json:"vanished_files,omitempty"`
+	Errors                []FileError `json:"errors,omitempty"`
+	Reproducible          bool        `json:"reproducible,omitempty"`
+	TarChecksum           string      `json:"tar_checksum,omitempty"`
+}
+
+// FileError records a file that could not be read during the backup
+type FileError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// SourceKey returns the source_path value for a set of backup sources: the path
+// itself for a single source, or the sorted paths joined by ", " for several.
+// The key does not depend on --source order, so it is a stable retention group key.
+func SourceKey(sourcePaths []string) string {
+	if len(sourcePaths) == 1 {
+		return sourcePaths[0]
+	}
+	sorted := append([]string(nil), sourcePaths...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
 }
 
 // CreatedBy holds information about the tool that created the backup
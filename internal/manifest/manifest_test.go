@@ -58,6 +58,16 @@ func TestManifestPath(t *testing.T) {
 			backupPath: "backup_data_20260215_120000.tar.lz4.age",
 			want:       "backup_data_20260215_120000_manifest.json",
 		},
+		{
+			name:       "gpg encrypted s2",
+			backupPath: "backup_data_20260215_120000.tar.s2.gpg",
+			want:       "backup_data_20260215_120000_manifest.json",
+		},
+		{
+			name:       "age encrypted s2",
+			backupPath: "backup_data_20260215_120000.tar.s2.age",
+			want:       "backup_data_20260215_120000_manifest.json",
+		},
 		{
 			name:       "age encrypted gzip",
 			backupPath: "backup_data_20260215_120000.tar.gz.age",
@@ -110,6 +120,20 @@ func TestNew(t *testing.T) {
 	assert.WithinDuration(t, time.Now().UTC(), m.CreatedAt, 2*time.Second)
 }
 
+func TestSourceKey(t *testing.T) {
+	assert.Equal(t, "/etc", SourceKey([]string{"/etc"}))
+
+	// Independent of source order
+	want := "/etc, /home, /var/lib/app"
+	assert.Equal(t, want, SourceKey([]string{"/home", "/var/lib/app", "/etc"}))
+	assert.Equal(t, want, SourceKey([]string{"/etc", "/home", "/var/lib/app"}))
+
+	// Input is not modified
+	in := []string{"/b", "/a"}
+	SourceKey(in)
+	assert.Equal(t, []string{"/b", "/a"}, in)
+}
+
 func TestWrite(t *testing.T) {
 	tmpDir := t.TempDir()
 	manifestPath := filepath.Join(tmpDir, "test.json")
@@ -198,6 +222,55 @@ func TestReadWrite_RoundTrip(t *testing.T) {
 	assert.Equal(t, m1.CreatedBy.Hostname, m2.CreatedBy.Hostname)
 }
 
+func TestReadWrite_Errors(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "partial.json")
+
+	m1, err := New("/source", "backup.tar.gz.gpg", "v1.0.0", "gzip", "gpg")
+	require.NoError(t, err)
+	m1.ChecksumValue = "abc123"
+	m1.Errors = []FileError{
+		{Path: "/source/secret", Error: "open /source/secret: permission denied"},
+	}
+	require.NoError(t, m1.Write(manifestPath, nil))
+
+	data, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"errors"`)
+	assert.Contains(t, string(data), `"path": "/source/secret"`)
+
+	m2, err := Read(manifestPath)
+	require.NoError(t, err)
+	assert.Equal(t, m1.Errors, m2.Errors)
+}
+
+func TestReadWrite_CompressionLevel(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "lz4.json")
+
+	// lz4's fastest level is 0, which must still be recorded
+	m1, err := New("/source", "backup.tar.lz4.age", "v1.0.0", "lz4", "age")
+	require.NoError(t, err)
+	m1.ChecksumValue = "abc123"
+	level := 0
+	m1.CompressionLevel = &level
+	require.NoError(t, m1.Write(manifestPath, nil))
+
+	data, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"compression_level": 0`)
+
+	m2, err := Read(manifestPath)
+	require.NoError(t, err)
+	require.NotNil(t, m2.CompressionLevel)
+	assert.Equal(t, 0, *m2.CompressionLevel)
+
+	// Without a level (none, or manifests written before levels were recorded)
+	m1.CompressionLevel = nil
+	require.NoError(t, m1.Write(manifestPath, nil))
+	data, err = os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "compression_level")
+}
+
 func TestValidate_Valid(t *testing.T) {
 	m, err := New("/source", "backup.tar.gz.gpg", "v1.0.0", "gzip", "gpg")
 	require.NoError(t, err)
@@ -206,6 +206,16 @@ func TestIsBackupFile(t *testing.T) {
 			filename: "backup_20240207_183000.tar.lz4.age",
 			want:     true,
 		},
+		{
+			name:     "s2 gpg",
+			filename: "backup_20240207_183000.tar.s2.gpg",
+			want:     true,
+		},
+		{
+			name:     "s2 age",
+			filename: "backup_20240207_183000.tar.s2.age",
+			want:     true,
+		},
 		{
 			name:     "wrong extension",
 			filename: "backup_20240207_183000.tar.bz2.gpg",
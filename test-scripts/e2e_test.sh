@@ -629,11 +629,141 @@ fi
 
 pass "Lz4+AGE: All files match source"
 
+# ═══════════════════════════════════════════
+# COMPRESSION S2 PIPELINE
+# ═══════════════════════════════════════════
+
+# --- Step 70: Compression S2 Backup ---
+step "Running backup with --compression s2"
+S2_BACKUP_DIR="$TMPDIR_E2E/s2-backups"
+S2_RESTORE_DIR="$TMPDIR_E2E/s2-restore"
+mkdir -p "$S2_BACKUP_DIR" "$S2_RESTORE_DIR"
+
+"$BINARY" backup \
+  --source "$SOURCE_DIR" \
+  --dest "$S2_BACKUP_DIR" \
+  --public-key "$PUBLIC_KEY" \
+  --compression s2 \
+  --verbose
+
+S2_BACKUP_FILE=$(find "$S2_BACKUP_DIR" -name "backup_*.tar.s2.gpg" -not -name "*.tmp" | head -1)
+test -n "$S2_BACKUP_FILE" || fail "No s2 backup file found (.tar.s2.gpg)"
+test -s "$S2_BACKUP_FILE" || fail "S2 backup file is empty"
+
+# Verify manifest exists and records compression=s2
+S2_MANIFEST=$(find "$S2_BACKUP_DIR" -name "*_manifest.json" | head -1)
+test -n "$S2_MANIFEST" || fail "No manifest for s2 backup"
+grep -q '"compression": "s2"' "$S2_MANIFEST" || fail "Manifest should record compression as 's2'"
+
+pass "S2 backup created: $(basename "$S2_BACKUP_FILE")"
+
+# --- Step 71: Compression S2 Quick Verify ---
+step "Running quick verify on s2 backup"
+"$BINARY" verify --file "$S2_BACKUP_FILE" --quick
+pass "S2 quick verify passed"
+
+# --- Step 72: Compression S2 Full Verify ---
+step "Running full verify on s2 backup"
+"$BINARY" verify --file "$S2_BACKUP_FILE" --private-key "$PRIVATE_KEY" --verbose
+pass "S2 full verify passed"
+
+# --- Step 73: Compression S2 Restore ---
+step "Running restore on s2 backup"
+"$BINARY" restore \
+  --file "$S2_BACKUP_FILE" \
+  --dest "$S2_RESTORE_DIR" \
+  --private-key "$PRIVATE_KEY" \
+  --verbose
+
+S2_RESTORED_SOURCE="$S2_RESTORE_DIR/source"
+test -d "$S2_RESTORED_SOURCE" || fail "S2 restored source directory not found"
+pass "S2 restore completed"
+
+# --- Step 74: Compression S2 Diff ---
+step "Comparing s2 restored data with source"
+diff -r "$SOURCE_DIR" "$S2_RESTORED_SOURCE" || fail "S2 restored files differ from source"
+
+if [ -L "$S2_RESTORED_SOURCE/link_to_small.txt" ]; then
+  TARGET=$(readlink "$S2_RESTORED_SOURCE/link_to_small.txt")
+  test "$TARGET" = "small.txt" || fail "Symlink target mismatch: got '$TARGET', want 'small.txt'"
+  pass "S2: Symlink preserved correctly"
+else
+  fail "S2: Symlink not preserved"
+fi
+
+pass "S2: All files match source"
+
+# ═══════════════════════════════════════════
+# COMPRESSION S2 + AGE PIPELINE
+# ═══════════════════════════════════════════
+
+# --- Step 80: S2 + AGE Backup ---
+step "Running backup with --compression s2 --encryption age"
+S2_AGE_BACKUP_DIR="$TMPDIR_E2E/s2-age-backups"
+S2_AGE_RESTORE_DIR="$TMPDIR_E2E/s2-age-restore"
+mkdir -p "$S2_AGE_BACKUP_DIR" "$S2_AGE_RESTORE_DIR"
+
+"$BINARY" backup \
+  --source "$SOURCE_DIR" \
+  --dest "$S2_AGE_BACKUP_DIR" \
+  --public-key "$AGE_RECIPIENT" \
+  --encryption age \
+  --compression s2 \
+  --verbose
+
+S2_AGE_BACKUP_FILE=$(find "$S2_AGE_BACKUP_DIR" -name "backup_*.tar.s2.age" -not -name "*.tmp" | head -1)
+test -n "$S2_AGE_BACKUP_FILE" || fail "No s2+AGE backup file found (.tar.s2.age)"
+test -s "$S2_AGE_BACKUP_FILE" || fail "S2+AGE backup file is empty"
+
+# Verify manifest records both compression and encryption
+S2_AGE_MANIFEST=$(find "$S2_AGE_BACKUP_DIR" -name "*_manifest.json" | head -1)
+test -n "$S2_AGE_MANIFEST" || fail "No manifest for s2+AGE backup"
+grep -q '"compression": "s2"' "$S2_AGE_MANIFEST" || fail "Manifest should record compression as 's2'"
+grep -q '"encryption": "age"' "$S2_AGE_MANIFEST" || fail "Manifest should record encryption as 'age'"
+
+pass "S2+AGE backup created: $(basename "$S2_AGE_BACKUP_FILE")"
+
+# --- Step 81: S2 + AGE Quick Verify ---
+step "Running quick verify on s2+AGE backup"
+"$BINARY" verify --file "$S2_AGE_BACKUP_FILE" --quick
+pass "S2+AGE quick verify passed"
+
+# --- Step 82: S2 + AGE Full Verify ---
+step "Running full verify on s2+AGE backup"
+"$BINARY" verify --file "$S2_AGE_BACKUP_FILE" --private-key "$AGE_KEY_FILE" --verbose
+pass "S2+AGE full verify passed"
+
+# --- Step 83: S2 + AGE Restore ---
+step "Running restore on s2+AGE backup"
+"$BINARY" restore \
+  --file "$S2_AGE_BACKUP_FILE" \
+  --dest "$S2_AGE_RESTORE_DIR" \
+  --private-key "$AGE_KEY_FILE" \
+  --verbose
+
+S2_AGE_RESTORED_SOURCE="$S2_AGE_RESTORE_DIR/source"
+test -d "$S2_AGE_RESTORED_SOURCE" || fail "S2+AGE restored source directory not found"
+pass "S2+AGE restore completed"
+
+# --- Step 84: S2 + AGE Diff ---
+step "Comparing s2+AGE restored data with source"
+diff -r "$SOURCE_DIR" "$S2_AGE_RESTORED_SOURCE" || fail "S2+AGE restored files differ from source"
+
+if [ -L "$S2_AGE_RESTORED_SOURCE/link_to_small.txt" ]; then
+  TARGET=$(readlink "$S2_AGE_RESTORED_SOURCE/link_to_small.txt")
+  test "$TARGET" = "small.txt" || fail "Symlink target mismatch: got '$TARGET', want 'small.txt'"
+  pass "S2+AGE: Symlink preserved correctly"
+else
+  fail "S2+AGE: Symlink not preserved"
+fi
+
+pass "S2+AGE: All files match source"
+
 # ═══════════════════════════════════════════
 # MANIFEST-FIRST MANAGEMENT TESTS (issue #45)
 # ═══════════════════════════════════════════
 
-# --- Step 70: Managed vs Orphan list output ---
+# --- Step 90: Managed vs Orphan list output ---
 step "Testing managed vs orphan list output"
 MFM_BACKUP_DIR="$TMPDIR_E2E/mfm-backups"
 MFM_SOURCE_A="$TMPDIR_E2E/mfm-source-a"
@@ -664,7 +794,7 @@ echo "$LIST_MFM_OUTPUT" | grep -q "(no manifest)" || fail "Orphan section should
 
 pass "List correctly shows managed and orphan sections"
 
-# --- Step 71: Orphan excluded from retention ---
+# --- Step 91: Orphan excluded from retention ---
 step "Testing orphan excluded from retention"
 ORFRET_BACKUP_DIR="$TMPDIR_E2E/orfret-backups"
 ORFRET_SOURCE="$TMPDIR_E2E/orfret-source"
@@ -719,7 +849,7 @@ done
 
 pass "Orphans correctly excluded from retention"
 
-# --- Step 72: Scoped retention with two sources ---
+# --- Step 92: Scoped retention with two sources ---
 step "Testing scoped retention with two sources"
 SCOPED_BACKUP_DIR="$TMPDIR_E2E/scoped-backups"
 SCOPED_SOURCE_A="$TMPDIR_E2E/scoped-src-a"